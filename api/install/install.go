@@ -34,3 +34,13 @@ func InstallOperatorAPIsOnboarding(scheme *runtime.Scheme) *runtime.Scheme {
 
 	return scheme
 }
+
+// InstallOperatorAPIsMCP installs the APIs required for managing quotas in ManagedControlPlane clusters.
+// Next to the QuotaIncrease resource, this includes the CRD API, because the QuotaIncrease CRD is deployed into the MCP clusters by the controller.
+func InstallOperatorAPIsMCP(scheme *runtime.Scheme) *runtime.Scheme {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextv1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	return scheme
+}
@@ -2,10 +2,15 @@ package v1alpha1
 
 import (
 	"slices"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -13,16 +18,119 @@ import (
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster,shortName=qcfg
 // +kubebuilder:metadata:labels="openmcp.cloud/cluster=platform"
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="Active",type=integer,JSONPath=`.status.activeGeneration`
+// +kubebuilder:printcolumn:name="Observed",type=integer,JSONPath=`.status.observedGeneration`
+// +kubebuilder:printcolumn:name="Rollout",type=string,JSONPath=`.status.rollout.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type QuotaServiceConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec QuotaServiceConfigSpec `json:"spec,omitempty"`
+	Spec   QuotaServiceConfigSpec   `json:"spec,omitempty"`
+	Status QuotaServiceConfigStatus `json:"status,omitempty"`
 }
 
+// QuotaServiceConfigStatus is written by the controller and reports which generation of the config is in use.
+type QuotaServiceConfigStatus struct {
+	// ObservedGeneration is the latest generation of the config which has been validated by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ActiveGeneration is the generation of the config which is currently used by the controller.
+	// It differs from the observed generation if the latest generation has been rejected, in which case the last valid generation is still used.
+	// +optional
+	ActiveGeneration int64 `json:"activeGeneration,omitempty"`
+	// Conditions contains the conditions of the config.
+	// The 'Valid' condition reports whether the latest generation passed the validation.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Rollout reports the progress of a staged rollout of the active generation.
+	// It is only set if the active generation has a rollout policy.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+}
+
+// RolloutStatus reports the progress of a staged rollout.
+type RolloutStatus struct {
+	// Generation is the generation of the config which is rolled out.
+	Generation int64 `json:"generation"`
+	// StableGeneration is the generation of the config which is still used for namespaces that have not been updated yet.
+	StableGeneration int64 `json:"stableGeneration"`
+	// StableSpec is the spec of the stable generation.
+	// It is required to continue the rollout after a restart of the controller.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	StableSpec *QuotaServiceConfigSpec `json:"stableSpec,omitempty"`
+	// Phase is the phase of the rollout.
+	// +kubebuilder:validation:Enum=Progressing;Halted;Completed
+	Phase RolloutPhase `json:"phase"`
+	// UpdatedNamespaces is the number of affected namespaces which use the new generation.
+	// +optional
+	UpdatedNamespaces int32 `json:"updatedNamespaces,omitempty"`
+	// TotalNamespaces is the number of namespaces whose quota definition differs between the stable and the new generation.
+	// +optional
+	TotalNamespaces int32 `json:"totalNamespaces,omitempty"`
+	// BelowUsageNamespaces is the number of updated namespaces whose usage exceeds the new quota.
+	// +optional
+	BelowUsageNamespaces int32 `json:"belowUsageNamespaces,omitempty"`
+	// LastBatchTime is the time at which the last batch of namespaces has been updated.
+	// +optional
+	LastBatchTime *metav1.Time `json:"lastBatchTime,omitempty"`
+	// Message is a human-readable description of the state of the rollout.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type RolloutPhase string
+
+const (
+	// ROLLOUT_PROGRESSING means that the namespaces are updated batch by batch.
+	ROLLOUT_PROGRESSING RolloutPhase = "Progressing"
+	// ROLLOUT_HALTED means that the rollout has been stopped, because too many namespaces ended up below usage.
+	// Namespaces which have not been updated yet keep using the stable generation until a new generation is created.
+	ROLLOUT_HALTED RolloutPhase = "Halted"
+	// ROLLOUT_COMPLETED means that all namespaces use the new generation.
+	ROLLOUT_COMPLETED RolloutPhase = "Completed"
+)
+
+// DefaultRolloutPause is the default time to wait between two batches of a staged rollout.
+const DefaultRolloutPause = time.Minute
+
 type QuotaServiceConfigSpec struct {
 	// Quotas is a list of QuotaDefinitions.
 	Quotas []*QuotaDefinition `json:"quotas"`
+	// Rollout specifies how a new generation of this config is rolled out to the onboarding namespaces.
+	// If nil, a new generation is applied to all namespaces at once.
+	// Only applies to changes of the config, the first generation is always applied to all namespaces at once.
+	// +optional
+	Rollout *RolloutPolicy `json:"rollout,omitempty"`
+}
+
+// RolloutPolicy configures a staged rollout of new generations of the QuotaServiceConfig.
+// Only namespaces whose quota definition differs between the previous and the new generation are part of the rollout.
+type RolloutPolicy struct {
+	// Canary is a label selector for namespaces which are updated first, in a batch of their own.
+	// If nil, there are no canary namespaces.
+	// +optional
+	Canary *metav1.LabelSelector `json:"canary,omitempty"`
+	// BatchSize is the number of namespaces which are updated per batch, either as absolute number or as percentage of the affected namespaces.
+	// Percentages are rounded up. Defaults to 100%.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	BatchSize *intstr.IntOrString `json:"batchSize,omitempty"`
+	// Pause is the time to wait between two batches. Defaults to 1m.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+	// MaxBelowUsage is the number of updated namespaces whose usage exceeds the new quota at which the rollout is halted.
+	// 0 means that the rollout is never halted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBelowUsage int32 `json:"maxBelowUsage,omitempty"`
 }
 
 type QuotaDefinition struct {
@@ -36,6 +144,10 @@ type QuotaDefinition struct {
 	// ResourceQuotaTemplate is the template for the ResourceQuota that should be created for all namespaces which match the selector.
 	// +kubebuilder:validation:Required
 	ResourceQuotaTemplate *ResourceQuotaTemplate `json:"template"`
+	// LimitRangeTemplate is the template for a LimitRange that should be created next to the ResourceQuota.
+	// If nil, no LimitRange is created.
+	// +optional
+	LimitRangeTemplate *LimitRangeTemplate `json:"limitRangeTemplate,omitempty"`
 	// Mode is the mode in which the quota should be increased.
 	// cumulative: multiple quota increases for the same resource will add up.
 	// maximum: the highest quota increase for the same resource will be used.
@@ -45,6 +157,30 @@ type QuotaDefinition struct {
 	// DeleteIneffectiveQuotas specifies whether ResourceQuotas that are no longer effective should be deleted automatically.
 	// +optional
 	DeleteIneffectiveQuotas bool `json:"deleteIneffectiveQuotas,omitempty"`
+	// QuotaIncreaseLimits restricts the QuotaIncreases which are taken into account per namespace.
+	// If nil, all QuotaIncreases are taken into account.
+	// +optional
+	QuotaIncreaseLimits *QuotaIncreaseLimits `json:"quotaIncreaseLimits,omitempty"`
+	// Target specifies in which clusters the namespaces selected by this quota definition are located.
+	// onboarding: namespaces in the onboarding cluster are selected (default).
+	// mcp: namespaces in ManagedControlPlane clusters are selected, the clusters can be filtered via the ClusterSelector.
+	// Namespaces and QuotaIncreases in MCP clusters are not watched, changes are only applied when the clusters are re-evaluated periodically (see the '--mcp-resync-interval' flag of the controller).
+	// +kubebuilder:validation:Enum=onboarding;mcp
+	// +optional
+	Target QuotaTarget `json:"target,omitempty"`
+	// ClusterSelector is a label selector for the openMCP Cluster resources on the platform cluster this quota definition should be applied to.
+	// Only clusters with the 'mcp' purpose are considered.
+	// Must only be set if Target is 'mcp'. If nil, all MCP clusters are selected.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// AdoptionPolicy specifies how ResourceQuotas in the selected namespaces which have not been created by the Quota Controller are handled.
+	// ignore: unmanaged ResourceQuotas are left untouched. If one has the same name as the generated ResourceQuota, the namespace is not reconciled.
+	// adopt-by-name: an unmanaged ResourceQuota with the same name as the generated one is taken over, all others are left untouched (default).
+	// replace: an unmanaged ResourceQuota with the same name as the generated one is taken over, all others are deleted.
+	// merge-as-increase: unmanaged ResourceQuotas are converted into a single QuotaIncrease preserving their limits and deleted afterwards, one with the same name as the generated ResourceQuota is taken over instead of being deleted.
+	// +kubebuilder:validation:Enum=ignore;adopt-by-name;replace;merge-as-increase
+	// +optional
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
 }
 
 type ResourceQuotaTemplate struct {
@@ -56,6 +192,35 @@ type ResourceQuotaTemplate struct {
 	Spec corev1.ResourceQuotaSpec `json:"spec"`
 }
 
+type LimitRangeTemplate struct {
+	// Annotations are the annotations that should be added to the generated LimitRange.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Labels are the labels that should be added to the generated LimitRange.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Spec is the spec of the generated LimitRange.
+	Spec corev1.LimitRangeSpec `json:"spec"`
+	// ScaleWithQuotaIncreases specifies whether the limits of the generated LimitRange should be scaled along with the ResourceQuota.
+	// If true, 'max' and 'default' values for a resource are multiplied with the factor by which the 'limits.<resource>' quota has been increased,
+	// and 'defaultRequest' values are multiplied with the factor by which the 'requests.<resource>' (or '<resource>') quota has been increased.
+	// Scaled 'defaultRequest' values are capped at the 'default' and 'max' values of the same resource.
+	// +optional
+	ScaleWithQuotaIncreases bool `json:"scaleWithQuotaIncreases,omitempty"`
+}
+
+type QuotaIncreaseLimits struct {
+	// MaxCount is the maximum number of QuotaIncreases per namespace that are taken into account.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
+	// MaxIncrease is the maximum amount per resource by which the QuotaIncreases in a namespace may increase the quota, compared to the template.
+	// Resources which are not listed are not limited.
+	// +optional
+	MaxIncrease corev1.ResourceList `json:"maxIncrease,omitempty"`
+	// DeleteRejected specifies whether QuotaIncreases that are rejected due to the limits should be deleted automatically.
+	// +optional
+	DeleteRejected bool `json:"deleteRejected,omitempty"`
+}
+
 type QuotaIncreaseOperatingMode string
 
 const (
@@ -67,9 +232,35 @@ const (
 	SINGULAR QuotaIncreaseOperatingMode = "singular"
 )
 
+type QuotaTarget string
+
+const (
+	// TARGET_ONBOARDING means that the quota definition is applied to namespaces in the onboarding cluster.
+	TARGET_ONBOARDING QuotaTarget = "onboarding"
+	// TARGET_MCP means that the quota definition is applied to namespaces in ManagedControlPlane clusters.
+	TARGET_MCP QuotaTarget = "mcp"
+)
+
+type AdoptionPolicy string
+
+const (
+	// ADOPTION_IGNORE means that unmanaged ResourceQuotas are never modified.
+	ADOPTION_IGNORE AdoptionPolicy = "ignore"
+	// ADOPTION_ADOPT_BY_NAME means that an unmanaged ResourceQuota with the name of the generated one is taken over.
+	ADOPTION_ADOPT_BY_NAME AdoptionPolicy = "adopt-by-name"
+	// ADOPTION_REPLACE means that an unmanaged ResourceQuota with the name of the generated one is taken over and all other unmanaged ResourceQuotas are deleted.
+	ADOPTION_REPLACE AdoptionPolicy = "replace"
+	// ADOPTION_MERGE_AS_INCREASE means that unmanaged ResourceQuotas are converted into a single QuotaIncrease per namespace.
+	ADOPTION_MERGE_AS_INCREASE AdoptionPolicy = "merge-as-increase"
+)
+
 var (
 	// SUPPORTED_OPERATING_MODES contains all supported operating modes. Used for validation.
 	SUPPORTED_OPERATING_MODES = []QuotaIncreaseOperatingMode{CUMULATIVE, MAXIMUM, SINGULAR}
+	// SUPPORTED_TARGETS contains all supported targets. Used for validation.
+	SUPPORTED_TARGETS = []QuotaTarget{TARGET_ONBOARDING, TARGET_MCP}
+	// SUPPORTED_ADOPTION_POLICIES contains all supported adoption policies. Used for validation.
+	SUPPORTED_ADOPTION_POLICIES = []AdoptionPolicy{ADOPTION_IGNORE, ADOPTION_ADOPT_BY_NAME, ADOPTION_REPLACE, ADOPTION_MERGE_AS_INCREASE}
 )
 
 // QuotaServiceConfigList contains a list of QuotaServiceConfig
@@ -92,6 +283,63 @@ func (d *QuotaDefinition) BaseResourceQuota() *corev1.ResourceQuota {
 	return res.DeepCopy()
 }
 
+// BaseLimitRange returns a LimitRange object based on the configured template, or nil if no LimitRange template is configured.
+// A deep copy is returned, the returned object can be modified without affecting the original template.
+// Note that the namespace is missing and has to be set afterwards.
+func (d *QuotaDefinition) BaseLimitRange() *corev1.LimitRange {
+	if d.LimitRangeTemplate == nil {
+		return nil
+	}
+	res := &corev1.LimitRange{}
+	res.SetName(d.Name)
+	res.SetAnnotations(d.LimitRangeTemplate.Annotations)
+	res.SetLabels(d.LimitRangeTemplate.Labels)
+	res.Spec = d.LimitRangeTemplate.Spec
+	return res.DeepCopy()
+}
+
+// GetTarget returns the target of the quota definition.
+// If no target is specified, TARGET_ONBOARDING is returned.
+func (d *QuotaDefinition) GetTarget() QuotaTarget {
+	if d.Target == "" {
+		return TARGET_ONBOARDING
+	}
+	return d.Target
+}
+
+// GetAdoptionPolicy returns the adoption policy of the quota definition.
+// If no adoption policy is specified, ADOPTION_ADOPT_BY_NAME is returned.
+func (d *QuotaDefinition) GetAdoptionPolicy() AdoptionPolicy {
+	if d.AdoptionPolicy == "" {
+		return ADOPTION_ADOPT_BY_NAME
+	}
+	return d.AdoptionPolicy
+}
+
+// GetPause returns the pause between two batches of the rollout.
+// If no pause is specified, DefaultRolloutPause is returned.
+func (rp *RolloutPolicy) GetPause() time.Duration {
+	if rp.Pause == nil {
+		return DefaultRolloutPause
+	}
+	return rp.Pause.Duration
+}
+
+// GetBatchSize returns the number of namespaces per batch for a rollout affecting the given number of namespaces.
+// Percentages are rounded up, the result is at least 1.
+// If no batch size is specified, all namespaces are updated in a single batch.
+func (rp *RolloutPolicy) GetBatchSize(total int) int {
+	if rp.BatchSize == nil {
+		return max(total, 1)
+	}
+	size, err := intstr.GetScaledValueFromIntOrPercent(rp.BatchSize, total, true)
+	if err != nil {
+		// cannot happen for validated configs
+		return max(total, 1)
+	}
+	return max(size, 1)
+}
+
 func init() {
 	SchemeBuilder.Register(&QuotaServiceConfig{}, &QuotaServiceConfigList{})
 }
@@ -113,6 +361,34 @@ func (spec QuotaServiceConfigSpec) ValidateRaw() field.ErrorList {
 		allErrs = append(allErrs, validateQuotaDefinition(qd, fldPath.Child("quotas").Index(i), knownNames)...)
 	}
 
+	if spec.Rollout != nil {
+		allErrs = append(allErrs, validateRolloutPolicy(spec.Rollout, fldPath.Child("rollout"))...)
+	}
+
+	return allErrs
+}
+
+func validateRolloutPolicy(rp *RolloutPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if rp.Canary != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(rp.Canary, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("canary"))...)
+	}
+	if rp.BatchSize != nil {
+		// scaling against 100 namespaces detects invalid or non-positive percentages as well as non-positive numbers
+		if size, err := intstr.GetScaledValueFromIntOrPercent(rp.BatchSize, 100, true); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("batchSize"), rp.BatchSize.String(), err.Error()))
+		} else if size <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("batchSize"), rp.BatchSize.String(), "BatchSize must be greater than zero"))
+		}
+	}
+	if rp.Pause != nil && rp.Pause.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("pause"), rp.Pause.String(), "Pause must not be negative"))
+	}
+	if rp.MaxBelowUsage < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxBelowUsage"), rp.MaxBelowUsage, "MaxBelowUsage must not be negative"))
+	}
+
 	return allErrs
 }
 
@@ -130,10 +406,23 @@ func validateQuotaDefinition(qd *QuotaDefinition, fldPath *field.Path, knownName
 		allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), qd.Name))
 	} else {
 		knownNames.Insert(qd.Name)
+		// the name is used as name for the generated ResourceQuota and LimitRange
+		for _, msg := range validation.IsDNS1123Subdomain(qd.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), qd.Name, msg))
+		}
+	}
+
+	if qd.Selector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(qd.Selector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("selector"))...)
 	}
 
 	if qd.ResourceQuotaTemplate == nil {
 		allErrs = append(allErrs, field.Required(fldPath.Child("template"), "ResourceQuotaTemplate must not be empty"))
+	} else {
+		allErrs = append(allErrs, validateResourceQuotaTemplate(qd.ResourceQuotaTemplate, fldPath.Child("template"))...)
+	}
+	if qd.LimitRangeTemplate != nil {
+		allErrs = append(allErrs, validateLimitRangeTemplate(qd.LimitRangeTemplate, fldPath.Child("limitRangeTemplate"))...)
 	}
 
 	if qd.Mode == "" {
@@ -141,7 +430,121 @@ func validateQuotaDefinition(qd *QuotaDefinition, fldPath *field.Path, knownName
 	} else if !slices.Contains(SUPPORTED_OPERATING_MODES, qd.Mode) {
 		allErrs = append(allErrs, field.NotSupported(fldPath.Child("mode"), qd.Mode, SUPPORTED_OPERATING_MODES))
 	}
+	if !slices.Contains(SUPPORTED_TARGETS, qd.GetTarget()) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("target"), qd.Target, SUPPORTED_TARGETS))
+	} else if qd.ClusterSelector != nil && qd.GetTarget() != TARGET_MCP {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("clusterSelector"), "ClusterSelector must only be set if Target is 'mcp'"))
+	} else if qd.ClusterSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(qd.ClusterSelector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("clusterSelector"))...)
+	}
+	if !slices.Contains(SUPPORTED_ADOPTION_POLICIES, qd.GetAdoptionPolicy()) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("adoptionPolicy"), qd.AdoptionPolicy, SUPPORTED_ADOPTION_POLICIES))
+	}
+	if qd.QuotaIncreaseLimits != nil {
+		limitsPath := fldPath.Child("quotaIncreaseLimits")
+		if qd.QuotaIncreaseLimits.MaxCount != nil && *qd.QuotaIncreaseLimits.MaxCount < 0 {
+			allErrs = append(allErrs, field.Invalid(limitsPath.Child("maxCount"), *qd.QuotaIncreaseLimits.MaxCount, "MaxCount must not be negative"))
+		}
+		for res, quantity := range qd.QuotaIncreaseLimits.MaxIncrease {
+			if quantity.Sign() < 0 {
+				allErrs = append(allErrs, field.Invalid(limitsPath.Child("maxIncrease").Key(string(res)), quantity.String(), "MaxIncrease must not be negative"))
+			}
+		}
+	}
+
+	return allErrs
+}
 
+var (
+	supportedResourceQuotaScopes = []corev1.ResourceQuotaScope{
+		corev1.ResourceQuotaScopeTerminating,
+		corev1.ResourceQuotaScopeNotTerminating,
+		corev1.ResourceQuotaScopeBestEffort,
+		corev1.ResourceQuotaScopeNotBestEffort,
+		corev1.ResourceQuotaScopePriorityClass,
+		corev1.ResourceQuotaScopeCrossNamespacePodAffinity,
+		corev1.ResourceQuotaScopeVolumeAttributesClass,
+	}
+	supportedScopeSelectorOperators = []corev1.ScopeSelectorOperator{
+		corev1.ScopeSelectorOpIn,
+		corev1.ScopeSelectorOpNotIn,
+		corev1.ScopeSelectorOpExists,
+		corev1.ScopeSelectorOpDoesNotExist,
+	}
+	supportedLimitTypes = []corev1.LimitType{
+		corev1.LimitTypeContainer,
+		corev1.LimitTypePod,
+		corev1.LimitTypePersistentVolumeClaim,
+	}
+)
+
+func validateResourceQuotaTemplate(tmpl *ResourceQuotaTemplate, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(tmpl.Labels, fldPath.Child("labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(tmpl.Annotations, fldPath.Child("annotations"))...)
+
+	specPath := fldPath.Child("spec")
+	allErrs = append(allErrs, validateResourceList(tmpl.Spec.Hard, specPath.Child("hard"))...)
+	for i, scope := range tmpl.Spec.Scopes {
+		if !slices.Contains(supportedResourceQuotaScopes, scope) {
+			allErrs = append(allErrs, field.NotSupported(specPath.Child("scopes").Index(i), scope, supportedResourceQuotaScopes))
+		}
+	}
+	if tmpl.Spec.ScopeSelector != nil {
+		for i, expr := range tmpl.Spec.ScopeSelector.MatchExpressions {
+			exprPath := specPath.Child("scopeSelector", "matchExpressions").Index(i)
+			if !slices.Contains(supportedResourceQuotaScopes, expr.ScopeName) {
+				allErrs = append(allErrs, field.NotSupported(exprPath.Child("scopeName"), expr.ScopeName, supportedResourceQuotaScopes))
+			}
+			if !slices.Contains(supportedScopeSelectorOperators, expr.Operator) {
+				allErrs = append(allErrs, field.NotSupported(exprPath.Child("operator"), expr.Operator, supportedScopeSelectorOperators))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateLimitRangeTemplate(tmpl *LimitRangeTemplate, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(tmpl.Labels, fldPath.Child("labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(tmpl.Annotations, fldPath.Child("annotations"))...)
+
+	for i, item := range tmpl.Spec.Limits {
+		itemPath := fldPath.Child("spec", "limits").Index(i)
+		if !slices.Contains(supportedLimitTypes, item.Type) {
+			allErrs = append(allErrs, field.NotSupported(itemPath.Child("type"), item.Type, supportedLimitTypes))
+		}
+		allErrs = append(allErrs, validateResourceList(item.Max, itemPath.Child("max"))...)
+		allErrs = append(allErrs, validateResourceList(item.Min, itemPath.Child("min"))...)
+		allErrs = append(allErrs, validateResourceList(item.Default, itemPath.Child("default"))...)
+		allErrs = append(allErrs, validateResourceList(item.DefaultRequest, itemPath.Child("defaultRequest"))...)
+		allErrs = append(allErrs, validateResourceList(item.MaxLimitRequestRatio, itemPath.Child("maxLimitRequestRatio"))...)
+		for _, res := range sets.List(sets.KeySet(item.Min)) {
+			minQ := item.Min[res]
+			if maxQ, ok := item.Max[res]; ok && minQ.Cmp(maxQ) > 0 {
+				allErrs = append(allErrs, field.Invalid(itemPath.Child("min").Key(string(res)), minQ.String(), "Min must not be greater than max"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateResourceList checks that all resource names are qualified names and that no quantity is negative.
+func validateResourceList(rl corev1.ResourceList, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, res := range sets.List(sets.KeySet(rl)) {
+		resPath := fldPath.Key(string(res))
+		for _, msg := range validation.IsQualifiedName(string(res)) {
+			allErrs = append(allErrs, field.Invalid(resPath, string(res), msg))
+		}
+		if quantity := rl[res]; quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(resPath, quantity.String(), "Quantity must not be negative"))
+		}
+	}
 	return allErrs
 }
 
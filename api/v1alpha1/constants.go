@@ -14,6 +14,10 @@ const (
 	// EffectAnnotation is the annotation used to store the effect of a QuotaIncrease.
 	EffectAnnotation = LabelPrefix + "/effect"
 
+	// EffectDetailsAnnotation is the annotation used to store the effect of a QuotaIncrease in a machine-readable format.
+	// It contains a JSON object with, per requested resource, the requested and the applied quantity and the reason why the requested quantity takes effect or not.
+	EffectDetailsAnnotation = LabelPrefix + "/effect-details"
+
 	// QuotaIncreaseOperationModeLabel is used to display the operation mode on QuotaIncreases and namespaces.
 	QuotaIncreaseOperationModeLabel = LabelPrefix + "/mode"
 
@@ -25,10 +29,69 @@ const (
 
 	// QuotaOperationLabel is a more specific version of the OperationLabel (openmcp.cloud/operation).
 	QuotaOperationLabel = LabelPrefix + "/operation"
+
+	// DriftPolicyAnnotation can be set on a namespace to specify how drift of the generated ResourceQuota is handled.
+	// Valid values are DriftPolicyCorrect (default) and DriftPolicyReportOnly.
+	DriftPolicyAnnotation = LabelPrefix + "/drift-policy"
+
+	// DriftAnnotation is set on generated ResourceQuotas whose spec deviates from the desired one and describes the deviation.
+	// It is only set if the drift is not corrected, which is the case for namespaces with the DriftPolicyReportOnly policy.
+	DriftAnnotation = LabelPrefix + "/drift"
+
+	// ConfigGenerationLabel is set on namespaces by a staged rollout and contains the generation of the QuotaServiceConfig the namespace has been updated to.
+	ConfigGenerationLabel = LabelPrefix + "/config-generation"
+
+	// AdoptedFromAnnotation is set on QuotaIncreases which have been created from pre-existing ResourceQuotas,
+	// either by the 'merge-as-increase' adoption policy or by the 'migrate' command.
+	// It contains the comma-separated names of the ResourceQuotas.
+	AdoptedFromAnnotation = LabelPrefix + "/adopted-from"
+
+	// ProvenanceAnnotation is set on generated ResourceQuotas and explains how their hard limits have been computed.
+	// It contains a JSON object with the quota definition, its operating mode and, per resource, the resulting hard limit, the base value from the template and the contributing QuotaIncreases.
+	ProvenanceAnnotation = LabelPrefix + "/provenance"
+)
+
+const (
+	// DriftPolicyCorrect means that manual changes to the generated ResourceQuota are reported and reverted.
+	DriftPolicyCorrect = "correct"
+	// DriftPolicyReportOnly means that manual changes to the generated ResourceQuota are reported, but not reverted.
+	DriftPolicyReportOnly = "reportOnly"
 )
 
 const (
 	// ActiveSingularQuotaIncreaseEffectPrefix is used to prefix the effect annotation of the active QuotaIncrease in singular mode.
 	// It is set even if the QuotaIncrease does not have any effect.
 	ActiveSingularQuotaIncreaseEffectPrefix = "[active]"
+
+	// RejectedQuotaIncreaseEffectPrefix is used to prefix the effect annotation of QuotaIncreases which have been rejected due to the limits of the quota definition.
+	// It is followed by the reason for the rejection.
+	RejectedQuotaIncreaseEffectPrefix = "[rejected]"
+)
+
+const (
+	// ConfigConditionValid is the type of the condition on the QuotaServiceConfig which reports whether its latest generation is valid.
+	ConfigConditionValid = "Valid"
+
+	// ConfigReasonValid is the reason of the 'Valid' condition if the latest generation is valid and in use.
+	ConfigReasonValid = "Valid"
+	// ConfigReasonValidationFailed is the reason of the 'Valid' condition if the latest generation has been rejected.
+	ConfigReasonValidationFailed = "ValidationFailed"
+
+	// ConfigConditionApplied is the type of the condition on the QuotaServiceConfig which reports whether the active generation could be applied to all namespaces and MCP clusters.
+	// Only errors which can be fixed by changing the config are taken into account.
+	ConfigConditionApplied = "Applied"
+
+	// ConfigReasonApplied is the reason of the 'Applied' condition if no reconcile failed because of the config.
+	ConfigReasonApplied = "Applied"
+	// ConfigReasonConfigError is the reason of the 'Applied' condition if reconciles failed because of the config.
+	ConfigReasonConfigError = "ConfigError"
+
+	// ConfigConditionInSync is the type of the condition on the QuotaServiceConfig which reports whether all generated ResourceQuotas match their desired state.
+	// Drift is only left uncorrected in namespaces with the 'reportOnly' drift policy.
+	ConfigConditionInSync = "InSync"
+
+	// ConfigReasonInSync is the reason of the 'InSync' condition if no drift is left uncorrected.
+	ConfigReasonInSync = "InSync"
+	// ConfigReasonDriftNotCorrected is the reason of the 'InSync' condition if generated ResourceQuotas deviate from their desired state and the drift is not corrected.
+	ConfigReasonDriftNotCorrected = "DriftNotCorrected"
 )
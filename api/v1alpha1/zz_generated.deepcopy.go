@@ -8,8 +8,39 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitRangeTemplate) DeepCopyInto(out *LimitRangeTemplate) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitRangeTemplate.
+func (in *LimitRangeTemplate) DeepCopy() *LimitRangeTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitRangeTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QuotaDefinition) DeepCopyInto(out *QuotaDefinition) {
 	*out = *in
@@ -23,6 +54,21 @@ func (in *QuotaDefinition) DeepCopyInto(out *QuotaDefinition) {
 		*out = new(ResourceQuotaTemplate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LimitRangeTemplate != nil {
+		in, out := &in.LimitRangeTemplate, &out.LimitRangeTemplate
+		*out = new(LimitRangeTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QuotaIncreaseLimits != nil {
+		in, out := &in.QuotaIncreaseLimits, &out.QuotaIncreaseLimits
+		*out = new(QuotaIncreaseLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaDefinition.
@@ -61,6 +107,33 @@ func (in *QuotaIncrease) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaIncreaseLimits) DeepCopyInto(out *QuotaIncreaseLimits) {
+	*out = *in
+	if in.MaxCount != nil {
+		in, out := &in.MaxCount, &out.MaxCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxIncrease != nil {
+		in, out := &in.MaxIncrease, &out.MaxIncrease
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaIncreaseLimits.
+func (in *QuotaIncreaseLimits) DeepCopy() *QuotaIncreaseLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaIncreaseLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QuotaIncreaseList) DeepCopyInto(out *QuotaIncreaseList) {
 	*out = *in
@@ -121,6 +194,7 @@ func (in *QuotaServiceConfig) DeepCopyInto(out *QuotaServiceConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaServiceConfig.
@@ -187,6 +261,11 @@ func (in *QuotaServiceConfigSpec) DeepCopyInto(out *QuotaServiceConfigSpec) {
 			}
 		}
 	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaServiceConfigSpec.
@@ -199,6 +278,33 @@ func (in *QuotaServiceConfigSpec) DeepCopy() *QuotaServiceConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaServiceConfigStatus) DeepCopyInto(out *QuotaServiceConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaServiceConfigStatus.
+func (in *QuotaServiceConfigStatus) DeepCopy() *QuotaServiceConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaServiceConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceQuotaTemplate) DeepCopyInto(out *ResourceQuotaTemplate) {
 	*out = *in
@@ -228,3 +334,57 @@ func (in *ResourceQuotaTemplate) DeepCopy() *ResourceQuotaTemplate {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutPolicy) DeepCopyInto(out *RolloutPolicy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BatchSize != nil {
+		in, out := &in.BatchSize, &out.BatchSize
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Pause != nil {
+		in, out := &in.Pause, &out.Pause
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutPolicy.
+func (in *RolloutPolicy) DeepCopy() *RolloutPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	if in.StableSpec != nil {
+		in, out := &in.StableSpec, &out.StableSpec
+		*out = new(QuotaServiceConfigSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastBatchTime != nil {
+		in, out := &in.LastBatchTime, &out.LastBatchTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// NewKubectlQuotaCommand returns the root command of the kubectl plugin.
+// If the binary is named 'kubectl-quota' and on the PATH, kubectl picks it up as 'kubectl quota'.
+func NewKubectlQuotaCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubectl-quota",
+		Short: "Inspect and request quota increases for namespaces managed by the quota platform service",
+		// the commands are usually invoked via kubectl, so there should be no usage output for runtime errors
+		SilenceUsage: true,
+	}
+	cmd.SetOut(os.Stdout)
+	cmd.SetErr(os.Stderr)
+
+	so := &SharedOptions{
+		RawSharedOptions: &RawSharedOptions{},
+	}
+	so.AddPersistentFlags(cmd)
+	cmd.AddCommand(NewShowCommand(so))
+	cmd.AddCommand(NewRequestCommand(so))
+	cmd.AddCommand(NewUseCommand(so))
+
+	return cmd
+}
+
+type RawSharedOptions struct {
+	Kubeconfig string `json:"kubeconfig"`
+	Context    string `json:"context"`
+	Namespace  string `json:"namespace"`
+}
+
+type SharedOptions struct {
+	*RawSharedOptions
+
+	// fields filled in Complete()
+	Client client.Client
+}
+
+func (o *SharedOptions) AddPersistentFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the KUBECONFIG environment variable or ~/.kube/config.")
+	cmd.PersistentFlags().StringVar(&o.Context, "context", "", "Name of the kubeconfig context to use. Defaults to the current context.")
+	cmd.PersistentFlags().StringVarP(&o.Namespace, "namespace", "n", "", "Namespace to work with. Defaults to the namespace of the current context.")
+}
+
+// Complete loads the kubeconfig, creates the client and determines the namespace.
+func (o *SharedOptions) Complete() error {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.Kubeconfig
+	clientCfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: o.Context})
+
+	restCfg, err := clientCfg.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	o.Client, err = client.New(restCfg, client.Options{Scheme: providerscheme.InstallOperatorAPIsOnboarding(runtime.NewScheme())})
+	if err != nil {
+		return fmt.Errorf("error creating client: %w", err)
+	}
+	if o.Namespace == "" {
+		o.Namespace, _, err = clientCfg.Namespace()
+		if err != nil {
+			return fmt.Errorf("error determining namespace: %w", err)
+		}
+	}
+	return nil
+}
+
+// getManagedNamespace fetches the namespace and verifies that it is managed by the quota platform service.
+func (o *SharedOptions) getManagedNamespace(ctx context.Context) (*corev1.Namespace, error) {
+	ns := &corev1.Namespace{}
+	if err := o.Client.Get(ctx, client.ObjectKey{Name: o.Namespace}, ns); err != nil {
+		return nil, fmt.Errorf("error getting namespace '%s': %w", o.Namespace, err)
+	}
+	if _, ok := ns.Labels[quotav1alpha1.ManagedByLabel]; !ok {
+		return nil, fmt.Errorf("namespace '%s' is not managed by the quota platform service", ns.Name)
+	}
+	return ns, nil
+}
+
+// getResourceQuota fetches the ResourceQuota which has been generated for the given namespace.
+// Returns nil if it does not exist (yet).
+func (o *SharedOptions) getResourceQuota(ctx context.Context, ns *corev1.Namespace) (*corev1.ResourceQuota, error) {
+	rqs := &corev1.ResourceQuotaList{}
+	if err := o.Client.List(ctx, rqs, client.InNamespace(ns.Name), client.MatchingLabels{
+		quotav1alpha1.ManagedByLabel:       ns.Labels[quotav1alpha1.ManagedByLabel],
+		quotav1alpha1.QuotaDefinitionLabel: ns.Labels[quotav1alpha1.BaseQuotaLabel],
+	}); err != nil {
+		return nil, fmt.Errorf("error listing ResourceQuotas in namespace '%s': %w", ns.Name, err)
+	}
+	if len(rqs.Items) == 0 {
+		return nil, nil
+	}
+	return &rqs.Items[0], nil
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+func NewRequestCommand(so *SharedOptions) *cobra.Command {
+	opts := &RequestOptions{
+		SharedOptions: so,
+	}
+	cmd := &cobra.Command{
+		Use:   "request",
+		Short: "Request a quota increase for the namespace by creating a QuotaIncrease",
+		Long: `Creates a QuotaIncrease in the namespace.
+--cpu and --memory are added to each matching entry of the namespace's ResourceQuota, e.g. --cpu is used for 'cpu', 'requests.cpu' and 'limits.cpu',
+depending on which of them are limited. If none of them is limited, 'requests.<resource>' and 'limits.<resource>' are requested.
+Any other resource can be requested via --hard, which takes the resource name as used in the ResourceQuota.`,
+		Example: `  kubectl quota request --cpu=4 --memory=8Gi
+  kubectl quota request --hard count/secrets=10 --name more-secrets`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Complete(); err != nil {
+				return err
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawRequestOptions struct {
+	CPU    string            `json:"cpu"`
+	Memory string            `json:"memory"`
+	Hard   map[string]string `json:"hard"`
+	Name   string            `json:"name"`
+	DryRun bool              `json:"dry-run"`
+}
+
+type RequestOptions struct {
+	*SharedOptions
+	RawRequestOptions
+
+	// fields filled in Complete()
+	Quantities map[string]resource.Quantity
+}
+
+func (o *RequestOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.CPU, "cpu", "", "Amount of CPU to request, e.g. '4' or '500m'.")
+	cmd.Flags().StringVar(&o.Memory, "memory", "", "Amount of memory to request, e.g. '8Gi'.")
+	cmd.Flags().StringToStringVar(&o.Hard, "hard", nil, "Amounts to request per resource name, e.g. 'count/secrets=10,requests.storage=100Gi'.")
+	cmd.Flags().StringVar(&o.Name, "name", "", "Name of the QuotaIncrease. A name is generated if not set.")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "If set, the QuotaIncrease is only printed, not created.")
+}
+
+func (o *RequestOptions) Complete() error {
+	o.Quantities = map[string]resource.Quantity{}
+	for flag, value := range map[string]string{"cpu": o.CPU, "memory": o.Memory} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for --%s: %w", value, flag, err)
+		}
+		o.Quantities[flag] = q
+	}
+	if len(o.Quantities) == 0 && len(o.Hard) == 0 {
+		return fmt.Errorf("at least one of --cpu, --memory or --hard must be set")
+	}
+	return o.SharedOptions.Complete()
+}
+
+func (o *RequestOptions) Run(ctx context.Context, out io.Writer) error {
+	ns, err := o.getManagedNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	rq, err := o.getResourceQuota(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	qi := &quotav1alpha1.QuotaIncrease{}
+	qi.SetGroupVersionKind(quotav1alpha1.GroupVersion.WithKind("QuotaIncrease"))
+	qi.SetNamespace(ns.Name)
+	if o.Name != "" {
+		qi.SetName(o.Name)
+	} else {
+		qi.SetGenerateName("request-")
+	}
+	qi.Spec.Hard = corev1.ResourceList{}
+	for res, q := range o.Quantities {
+		for _, rn := range resourceNamesFor(res, rq) {
+			qi.Spec.Hard[rn] = q
+		}
+	}
+	for res, value := range o.Hard {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid quantity '%s' for resource '%s': %w", value, res, err)
+		}
+		qi.Spec.Hard[corev1.ResourceName(res)] = q
+	}
+
+	if o.DryRun {
+		data, err := yaml.Marshal(qi)
+		if err != nil {
+			return fmt.Errorf("error marshalling QuotaIncrease: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	if err := o.Client.Create(ctx, qi); err != nil {
+		return fmt.Errorf("error creating QuotaIncrease: %w", err)
+	}
+	_, err = fmt.Fprintf(out, "QuotaIncrease '%s' created in namespace '%s' (%s).\nUse 'kubectl quota show' to see its effect once it has been evaluated.\n", qi.Name, ns.Name, resourceListAsString(qi.Spec.Hard))
+	return err
+}
+
+// resourceNamesFor returns the resource names of the ResourceQuota which correspond to the given compute resource ('cpu' or 'memory').
+// If the ResourceQuota is nil or does not limit the resource, 'requests.<resource>' and 'limits.<resource>' are returned.
+func resourceNamesFor(res string, rq *corev1.ResourceQuota) []corev1.ResourceName {
+	candidates := []corev1.ResourceName{corev1.ResourceName(res), corev1.ResourceName("requests." + res), corev1.ResourceName("limits." + res)}
+	names := []corev1.ResourceName{}
+	if rq != nil {
+		for _, c := range candidates {
+			if _, ok := rq.Spec.Hard[c]; ok {
+				names = append(names, c)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = candidates[1:]
+	}
+	return names
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+func NewShowCommand(so *SharedOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show [namespace]",
+		Short: "Show the quota of a namespace, its QuotaIncreases and their effects",
+		Long: `Shows the quota definition and operating mode of the namespace, the hard limits and usage of the generated ResourceQuota
+and all QuotaIncreases in the namespace together with their effect.
+The namespace can be given as argument or via --namespace, it defaults to the namespace of the current context.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				so.Namespace = args[0]
+			}
+			if err := so.Complete(); err != nil {
+				return err
+			}
+			return runShow(cmd.Context(), so, cmd.OutOrStdout())
+		},
+	}
+
+	return cmd
+}
+
+func runShow(ctx context.Context, so *SharedOptions, out io.Writer) error {
+	ns, err := so.getManagedNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	rq, err := so.getResourceQuota(ctx, ns)
+	if err != nil {
+		return err
+	}
+	qis := &quotav1alpha1.QuotaIncreaseList{}
+	if err := so.Client.List(ctx, qis, client.InNamespace(ns.Name)); err != nil {
+		return fmt.Errorf("error listing QuotaIncreases in namespace '%s': %w", ns.Name, err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "Namespace:\t%s\n", ns.Name)
+	fmt.Fprintf(w, "Quota definition:\t%s\n", valueOrNone(ns.Labels[quotav1alpha1.BaseQuotaLabel]))
+	mode := ns.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel]
+	fmt.Fprintf(w, "Mode:\t%s\n", valueOrNone(mode))
+	if mode == string(quotav1alpha1.SINGULAR) {
+		fmt.Fprintf(w, "Used QuotaIncrease:\t%s\n", valueOrNone(ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel]))
+	}
+	fmt.Fprintln(w)
+
+	if rq == nil {
+		fmt.Fprintln(w, "No ResourceQuota has been generated for this namespace yet.")
+	} else {
+		fmt.Fprintln(w, "RESOURCE\tHARD\tUSED\tUTILIZATION")
+		for _, res := range sets.List(sets.KeySet(rq.Spec.Hard)) {
+			hard := rq.Spec.Hard[res]
+			used := rq.Status.Used[res]
+			utilization := "-"
+			if !hard.IsZero() {
+				utilization = fmt.Sprintf("%.0f%%", used.AsApproximateFloat64()/hard.AsApproximateFloat64()*100)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res, hard.String(), used.String(), utilization)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if len(qis.Items) == 0 {
+		fmt.Fprintln(w, "No QuotaIncreases in this namespace.")
+	} else {
+		fmt.Fprintln(w, "QUOTAINCREASE\tAGE\tREQUESTED\tEFFECT")
+		for _, qi := range qis.Items {
+			effect, ok := qi.Annotations[quotav1alpha1.EffectAnnotation]
+			switch {
+			case !ok:
+				effect = "<not evaluated yet>"
+			case effect == "":
+				effect = "<none>"
+			}
+			age := duration.HumanDuration(time.Since(qi.CreationTimestamp.Time))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", qi.Name, age, resourceListAsString(qi.Spec.Hard), effect)
+		}
+	}
+	return w.Flush()
+}
+
+// resourceListAsString formats the resource list in the same way as the effect annotation of QuotaIncreases.
+func resourceListAsString(data corev1.ResourceList) string {
+	entries := []string{}
+	for _, res := range sets.List(sets.KeySet(data)) {
+		q := data[res]
+		entries = append(entries, fmt.Sprintf("%s: %s", res, q.String()))
+	}
+	return strings.Join(entries, ", ")
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
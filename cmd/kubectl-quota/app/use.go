@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+func NewUseCommand(so *SharedOptions) *cobra.Command {
+	opts := &UseOptions{
+		SharedOptions: so,
+	}
+	cmd := &cobra.Command{
+		Use:   "use <quotaincrease>",
+		Short: "Select the QuotaIncrease to use in a namespace with operating mode 'singular'",
+		Long: fmt.Sprintf(`Sets the '%s' label on the namespace, which selects the QuotaIncrease that is applied in operating mode 'singular'.
+The QuotaIncrease must exist in the namespace and the namespace must be in operating mode 'singular'.
+The label is updated with optimistic locking, so concurrent modifications of the namespace are not overwritten.`, quotav1alpha1.SingularQuotaIncreaseLabel),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.QuotaIncrease = args[0]
+			if err := opts.Complete(); err != nil {
+				return err
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawUseOptions struct {
+	QuotaIncrease string `json:"quotaIncrease"`
+	Force         bool   `json:"force"`
+}
+
+type UseOptions struct {
+	*SharedOptions
+	RawUseOptions
+}
+
+func (o *UseOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Set the label even if the namespace is not in operating mode 'singular'.")
+}
+
+func (o *UseOptions) Complete() error {
+	return o.SharedOptions.Complete()
+}
+
+func (o *UseOptions) Run(ctx context.Context, out io.Writer) error {
+	ns, err := o.getManagedNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	if mode := ns.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel]; mode != string(quotav1alpha1.SINGULAR) && !o.Force {
+		return fmt.Errorf("namespace '%s' is in operating mode '%s', the QuotaIncrease selection only has an effect in mode '%s' (use --force to set it anyway)", ns.Name, valueOrNone(mode), quotav1alpha1.SINGULAR)
+	}
+
+	qi := &quotav1alpha1.QuotaIncrease{}
+	if err := o.Client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: o.QuotaIncrease}, qi); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("QuotaIncrease '%s' not found in namespace '%s'", o.QuotaIncrease, ns.Name)
+		}
+		return fmt.Errorf("error getting QuotaIncrease '%s': %w", o.QuotaIncrease, err)
+	}
+
+	if ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] == qi.Name {
+		_, err := fmt.Fprintf(out, "QuotaIncrease '%s' is already used in namespace '%s'.\n", qi.Name, ns.Name)
+		return err
+	}
+	old := ns.DeepCopy()
+	ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = qi.Name
+	// the patch contains the resourceVersion, so it fails if the namespace has been modified in the meantime
+	if err := o.Client.Patch(ctx, ns, client.MergeFromWithOptions(old, client.MergeFromWithOptimisticLock{})); err != nil {
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("namespace '%s' has been modified concurrently, please try again: %w", ns.Name, err)
+		}
+		return fmt.Errorf("error patching namespace '%s': %w", ns.Name, err)
+	}
+	_, err = fmt.Fprintf(out, "Namespace '%s' now uses QuotaIncrease '%s' (%s).\n", ns.Name, qi.Name, resourceListAsString(qi.Spec.Hard))
+	return err
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openmcp-project/platform-service-quota/cmd/kubectl-quota/app"
+)
+
+func main() {
+	cmd := app.NewKubectlQuotaCommand()
+
+	if err := cmd.Execute(); err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+}
@@ -27,6 +27,11 @@ func NewPlatformServiceQuotaCommand() *cobra.Command {
 	so.AddPersistentFlags(cmd)
 	cmd.AddCommand(NewInitCommand(so))
 	cmd.AddCommand(NewRunCommand(so))
+	cmd.AddCommand(NewSimulateCommand(so))
+	cmd.AddCommand(NewDiffCommand(so))
+	cmd.AddCommand(NewValidateCommand(so))
+	cmd.AddCommand(NewReportCommand(so))
+	cmd.AddCommand(NewMigrateCommand(so))
 
 	return cmd
 }
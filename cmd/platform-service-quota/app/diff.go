@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+func NewDiffCommand(so *SharedOptions) *cobra.Command {
+	opts := &DiffOptions{
+		SharedOptions:     so,
+		OnboardingCluster: clusters.New("onboarding"),
+	}
+	cmd := &cobra.Command{
+		Use:   "diff <config-file>",
+		Short: "Show the impact of a QuotaServiceConfig change on the live onboarding cluster",
+		Long: `Reads a candidate QuotaServiceConfig from the given file and compares the result of applying it with the live state of the onboarding cluster.
+For each affected namespace, the change of the quota definition, the changed hard limits, the affected QuotaIncreases and the resources whose new limit would be below the current usage are reported.
+Only quota definitions targeting the onboarding cluster are evaluated. The onboarding cluster is only read, not modified.
+If the file contains multiple QuotaServiceConfigs, the one named after the provider name is used.
+The shared flags apart from --provider-name are ignored.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ConfigPath = args[0]
+			if err := opts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawDiffOptions struct {
+	ConfigPath string `json:"config-path"`
+	Output     string `json:"output"`
+}
+
+type DiffOptions struct {
+	*SharedOptions
+	RawDiffOptions
+	OnboardingCluster *clusters.Cluster
+}
+
+func (o *DiffOptions) AddFlags(cmd *cobra.Command) {
+	o.OnboardingCluster.RegisterConfigPathFlag(cmd.Flags())
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputFormatText, fmt.Sprintf("Output format. Valid values: %s, %s.", OutputFormatText, OutputFormatJSON))
+}
+
+func (o *DiffOptions) Complete() error {
+	if !slices.Contains([]string{OutputFormatText, OutputFormatJSON}, o.Output) {
+		return fmt.Errorf("unsupported output format '%s'", o.Output)
+	}
+	if err := o.OnboardingCluster.InitializeRESTConfig(); err != nil {
+		return err
+	}
+	if err := o.OnboardingCluster.InitializeClient(providerscheme.InstallOperatorAPIsOnboarding(runtime.NewScheme())); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *DiffOptions) Run(ctx context.Context, out io.Writer) error {
+	scheme := providerscheme.InstallOperatorAPIsPlatform(runtime.NewScheme())
+	docs, err := readDocuments(o.ConfigPath)
+	if err != nil {
+		return err
+	}
+	objs, err := decodeDocuments(scheme, docs)
+	if err != nil {
+		return err
+	}
+	cfgs := []*quotav1alpha1.QuotaServiceConfig{}
+	for _, obj := range objs {
+		if cfg, ok := obj.Object.(*quotav1alpha1.QuotaServiceConfig); ok {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	cfg, err := selectConfig(cfgs, o.ProviderName)
+	if err != nil {
+		return err
+	}
+	providerName := o.ProviderName
+	if providerName == "" {
+		providerName = cfg.Name
+	}
+
+	// read live state
+	cli := o.OnboardingCluster.Client()
+	nsList := &corev1.NamespaceList{}
+	if err := cli.List(ctx, nsList); err != nil {
+		return fmt.Errorf("error listing namespaces: %w", err)
+	}
+	qiList := &quotav1alpha1.QuotaIncreaseList{}
+	if err := cli.List(ctx, qiList); err != nil {
+		return fmt.Errorf("error listing QuotaIncreases: %w", err)
+	}
+	rqList := &corev1.ResourceQuotaList{}
+	if err := cli.List(ctx, rqList); err != nil {
+		return fmt.Errorf("error listing ResourceQuotas: %w", err)
+	}
+
+	// the computation logs are not relevant for the output
+	diffs, err := quota.Diff(logging.NewContextWithDiscard(ctx), cfg, providerName, pointerize(nsList.Items), pointerize(qiList.Items), pointerize(rqList.Items))
+	if err != nil {
+		return err
+	}
+
+	if o.Output == OutputFormatJSON {
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling diff to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+	return printDiff(out, diffs)
+}
+
+// pointerize converts a list of objects into a list of pointers to these objects.
+func pointerize[T any](items []T) []*T {
+	res := make([]*T, len(items))
+	for i := range items {
+		res[i] = &items[i]
+	}
+	return res
+}
+
+// printDiff prints the diff in a human-readable format.
+func printDiff(out io.Writer, diffs []quota.NamespaceDiff) error {
+	sb := &strings.Builder{}
+	if len(diffs) == 0 {
+		sb.WriteString("No namespaces affected.\n")
+	}
+	for i, d := range diffs {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "Namespace: %s\n", d.Namespace)
+		if d.DefinitionChanged() {
+			fmt.Fprintf(sb, "  Quota definition: %s -> %s\n", valueOrNone(d.OldQuotaDefinition), valueOrNone(d.NewQuotaDefinition))
+			if d.NewQuotaDefinition == "" {
+				sb.WriteString("    no quota definition matches anymore, the existing ResourceQuota is left unchanged\n")
+			}
+		}
+		if len(d.HardLimits) > 0 {
+			sb.WriteString("  Hard limits:\n")
+			for _, hl := range d.HardLimits {
+				fmt.Fprintf(sb, "    %s: %s -> %s (%s)\n", hl.Resource, quantityOrNone(hl.Old), quantityOrNone(hl.New), hardLimitDirection(hl))
+			}
+		}
+		if len(d.QuotaIncreases) > 0 {
+			sb.WriteString("  QuotaIncreases:\n")
+			for _, qi := range d.QuotaIncreases {
+				fmt.Fprintf(sb, "    %s: %s (effect: %s -> %s)\n", qi.Name, qi.Change, valueOrNone(qi.OldEffect), valueOrNone(qi.NewEffect))
+			}
+		}
+		if len(d.BelowUsage) > 0 {
+			sb.WriteString("  Below current usage:\n")
+			for _, v := range d.BelowUsage {
+				fmt.Fprintf(sb, "    %s: hard %s < used %s\n", v.Resource, v.Hard.String(), v.Used.String())
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		fmt.Fprintf(sb, "\n%d namespace(s) affected.\n", len(diffs))
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+// hardLimitDirection returns a short description of the direction of a hard limit change.
+func hardLimitDirection(hl quota.HardLimitChange) string {
+	switch {
+	case hl.Old == nil:
+		return "added"
+	case hl.New == nil:
+		return "removed"
+	case hl.New.Cmp(*hl.Old) > 0:
+		return "up"
+	default:
+		return "down"
+	}
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+func quantityOrNone(q *resource.Quantity) string {
+	if q == nil {
+		return "<none>"
+	}
+	return q.String()
+}
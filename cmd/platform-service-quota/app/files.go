@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// fileDocument is a single YAML or JSON document from a file.
+type fileDocument struct {
+	// File is the path of the file the document was read from.
+	File string
+	// Line is the line number (starting at 1) of the first line of the document within the file.
+	Line int
+	// Data is the content of the document.
+	Data []byte
+}
+
+// loadedObject is an object which has been decoded from a fileDocument.
+type loadedObject struct {
+	*fileDocument
+	Object runtime.Object
+}
+
+// String returns the origin of the document in the format '<file>:<line>'.
+func (d *fileDocument) String() string {
+	return fmt.Sprintf("%s:%d", d.File, d.Line)
+}
+
+// readDocuments reads all documents from the given paths.
+// Directories are walked recursively, only files with the extensions .yaml, .yml and .json are read from them.
+// YAML files may contain multiple documents, separated by '---'. Documents which contain only whitespace and comments are skipped.
+func readDocuments(paths ...string) ([]*fileDocument, error) {
+	files := []string{}
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read '%s': %w", p, err)
+		}
+		if !fi.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(path)) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error walking directory '%s': %w", p, err)
+		}
+	}
+
+	res := []*fileDocument{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read file '%s': %w", f, err)
+		}
+		res = append(res, splitDocuments(f, data)...)
+	}
+	return res, nil
+}
+
+// splitDocuments splits the given file content into its YAML documents.
+func splitDocuments(file string, data []byte) []*fileDocument {
+	res := []*fileDocument{}
+	cur := &fileDocument{File: file, Line: 1}
+	buf := &bytes.Buffer{}
+	flush := func() {
+		if !isEmptyDocument(buf.Bytes()) {
+			cur.Data = slices.Clone(buf.Bytes())
+			res = append(res, cur)
+		}
+		buf.Reset()
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimRight(text, " \t") == "---" {
+			flush()
+			cur = &fileDocument{File: file, Line: line + 1}
+			continue
+		}
+		buf.WriteString(text)
+		buf.WriteByte('\n')
+	}
+	flush()
+	return res
+}
+
+// isEmptyDocument returns true if the given document contains only whitespace and comments.
+func isEmptyDocument(data []byte) bool {
+	for l := range strings.Lines(string(data)) {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeDocuments decodes the given documents into typed objects, using the given scheme.
+// Documents of kinds which are not known to the scheme, or without a kind, are skipped, so that whole directories of manifests can be used as input.
+func decodeDocuments(scheme *runtime.Scheme, docs []*fileDocument) ([]*loadedObject, error) {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	res := make([]*loadedObject, 0, len(docs))
+	for _, doc := range docs {
+		obj, _, err := decoder.Decode(doc.Data, nil, nil)
+		if err != nil {
+			if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+				continue
+			}
+			return nil, fmt.Errorf("%s: unable to decode object: %w", doc.String(), err)
+		}
+		res = append(res, &loadedObject{fileDocument: doc, Object: obj})
+	}
+	return res, nil
+}
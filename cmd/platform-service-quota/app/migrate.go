@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+func NewMigrateCommand(so *SharedOptions) *cobra.Command {
+	opts := &MigrateOptions{
+		SharedOptions:     so,
+		OnboardingCluster: clusters.New("onboarding"),
+	}
+	cmd := &cobra.Command{
+		Use:   "migrate <config-file>",
+		Short: "Generate QuotaIncreases which preserve the limits of existing ResourceQuotas in the onboarding cluster",
+		Long: `Reads a QuotaServiceConfig from the given file and compares, for each namespace in the onboarding cluster with ResourceQuotas which have not been created by the Quota Controller,
+the current hard limits with the ones the matching quota definition would generate. Where the current limits are higher, a QuotaIncrease is generated which preserves them,
+respecting the operating mode of the quota definition. In singular mode, the namespace has to reference the generated QuotaIncrease.
+By default, the QuotaIncreases are only printed, so that they can be reviewed. With --apply, they are created and the namespaces are labeled, if required.
+Only quota definitions targeting the onboarding cluster are evaluated.
+If the file contains multiple QuotaServiceConfigs, the one named after the provider name is used.
+The shared flags apart from --provider-name are ignored.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ConfigPath = args[0]
+			if err := opts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawMigrateOptions struct {
+	ConfigPath string `json:"config-path"`
+	Output     string `json:"output"`
+	Apply      bool   `json:"apply"`
+}
+
+type MigrateOptions struct {
+	*SharedOptions
+	RawMigrateOptions
+	OnboardingCluster *clusters.Cluster
+}
+
+func (o *MigrateOptions) AddFlags(cmd *cobra.Command) {
+	o.OnboardingCluster.RegisterConfigPathFlag(cmd.Flags())
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputFormatYAML, fmt.Sprintf("Output format. Valid values: %s, %s, %s.", OutputFormatYAML, OutputFormatText, OutputFormatJSON))
+	cmd.Flags().BoolVar(&o.Apply, "apply", false, "If set, the generated QuotaIncreases are created in the onboarding cluster and namespaces in singular mode are labeled to reference them.")
+}
+
+func (o *MigrateOptions) Complete() error {
+	if !slices.Contains([]string{OutputFormatYAML, OutputFormatText, OutputFormatJSON}, o.Output) {
+		return fmt.Errorf("unsupported output format '%s'", o.Output)
+	}
+	if err := o.OnboardingCluster.InitializeRESTConfig(); err != nil {
+		return err
+	}
+	if err := o.OnboardingCluster.InitializeClient(providerscheme.InstallOperatorAPIsOnboarding(runtime.NewScheme())); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *MigrateOptions) Run(ctx context.Context, out io.Writer) error {
+	scheme := providerscheme.InstallOperatorAPIsPlatform(runtime.NewScheme())
+	docs, err := readDocuments(o.ConfigPath)
+	if err != nil {
+		return err
+	}
+	objs, err := decodeDocuments(scheme, docs)
+	if err != nil {
+		return err
+	}
+	cfgs := []*quotav1alpha1.QuotaServiceConfig{}
+	for _, obj := range objs {
+		if cfg, ok := obj.Object.(*quotav1alpha1.QuotaServiceConfig); ok {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	cfg, err := selectConfig(cfgs, o.ProviderName)
+	if err != nil {
+		return err
+	}
+	providerName := o.ProviderName
+	if providerName == "" {
+		providerName = cfg.Name
+	}
+
+	// read live state
+	cli := o.OnboardingCluster.Client()
+	nsList := &corev1.NamespaceList{}
+	if err := cli.List(ctx, nsList); err != nil {
+		return fmt.Errorf("error listing namespaces: %w", err)
+	}
+	qiList := &quotav1alpha1.QuotaIncreaseList{}
+	if err := cli.List(ctx, qiList); err != nil {
+		return fmt.Errorf("error listing QuotaIncreases: %w", err)
+	}
+	rqList := &corev1.ResourceQuotaList{}
+	if err := cli.List(ctx, rqList); err != nil {
+		return fmt.Errorf("error listing ResourceQuotas: %w", err)
+	}
+
+	// the computation logs are not relevant for the output
+	migrations, err := quota.Migrate(logging.NewContextWithDiscard(ctx), cfg, providerName, pointerize(nsList.Items), pointerize(qiList.Items), pointerize(rqList.Items))
+	if err != nil {
+		return err
+	}
+
+	switch o.Output {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(migrations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling migration to JSON: %w", err)
+		}
+		if _, err := fmt.Fprintln(out, string(data)); err != nil {
+			return err
+		}
+	case OutputFormatText:
+		if err := printMigration(out, migrations); err != nil {
+			return err
+		}
+	default:
+		if err := printMigrationYAML(out, migrations); err != nil {
+			return err
+		}
+	}
+
+	if o.Apply {
+		return applyMigration(ctx, cli, migrations)
+	}
+	return nil
+}
+
+// applyMigration creates the generated QuotaIncreases and labels the namespaces which have to reference them.
+func applyMigration(ctx context.Context, cli client.Client, migrations []quota.NamespaceMigration) error {
+	for _, m := range migrations {
+		if m.QuotaIncrease == nil {
+			continue
+		}
+		if err := cli.Create(ctx, m.QuotaIncrease.DeepCopy()); err != nil {
+			return fmt.Errorf("error creating QuotaIncrease '%s' in namespace '%s': %w", m.QuotaIncrease.Name, m.Namespace, err)
+		}
+		if m.UseQuotaIncrease {
+			ns := &corev1.Namespace{}
+			ns.SetName(m.Namespace)
+			patch := client.RawPatch(client.Merge.Type(), fmt.Appendf(nil, `{"metadata":{"labels":{%q:%q}}}`, quotav1alpha1.SingularQuotaIncreaseLabel, m.QuotaIncrease.Name))
+			if err := cli.Patch(ctx, ns, patch); err != nil {
+				return fmt.Errorf("error labeling namespace '%s': %w", m.Namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+// printMigrationYAML prints the generated QuotaIncreases as YAML documents, which can be applied with kubectl.
+// Required namespace labels and rejections are added as comments.
+func printMigrationYAML(out io.Writer, migrations []quota.NamespaceMigration) error {
+	sb := &strings.Builder{}
+	for _, m := range migrations {
+		if m.QuotaIncrease == nil {
+			continue
+		}
+		data, err := yaml.Marshal(m.QuotaIncrease)
+		if err != nil {
+			return fmt.Errorf("error marshalling QuotaIncrease to YAML: %w", err)
+		}
+		sb.WriteString("---\n")
+		if m.UseQuotaIncrease {
+			fmt.Fprintf(sb, "# namespace '%s' has to be labeled with '%s=%s'\n", m.Namespace, quotav1alpha1.SingularQuotaIncreaseLabel, m.QuotaIncrease.Name)
+		}
+		if m.Rejected != "" {
+			fmt.Fprintf(sb, "# would be rejected: %s\n", m.Rejected)
+		}
+		sb.Write(data)
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+// printMigration prints the migration in a human-readable format.
+func printMigration(out io.Writer, migrations []quota.NamespaceMigration) error {
+	sb := &strings.Builder{}
+	if len(migrations) == 0 {
+		sb.WriteString("No namespaces with unmanaged ResourceQuotas found.\n")
+	}
+	generated := 0
+	for i, m := range migrations {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "Namespace: %s\n", m.Namespace)
+		fmt.Fprintf(sb, "  Quota definition: %s (%s)\n", m.QuotaDefinition, m.Mode)
+		fmt.Fprintf(sb, "  Unmanaged ResourceQuotas: %s\n", valueOrNone(strings.Join(m.ResourceQuotas, ", ")))
+		if len(m.SkippedResourceQuotas) > 0 {
+			fmt.Fprintf(sb, "  Skipped ResourceQuotas with scopes: %s\n", strings.Join(m.SkippedResourceQuotas, ", "))
+		}
+		sb.WriteString("  Current hard limits:\n")
+		writeResourceList(sb, "    ", m.Current)
+		sb.WriteString("  Generated hard limits:\n")
+		writeResourceList(sb, "    ", m.Generated)
+		if m.QuotaIncrease == nil {
+			sb.WriteString("  no QuotaIncrease required\n")
+			continue
+		}
+		generated++
+		fmt.Fprintf(sb, "  QuotaIncrease %s:\n", m.QuotaIncrease.Name)
+		writeResourceList(sb, "    ", m.QuotaIncrease.Spec.Hard)
+		if m.UseQuotaIncrease {
+			fmt.Fprintf(sb, "    referenced via label '%s'\n", quotav1alpha1.SingularQuotaIncreaseLabel)
+		}
+		if m.Rejected != "" {
+			fmt.Fprintf(sb, "    would be rejected: %s\n", m.Rejected)
+		}
+	}
+	if len(migrations) > 0 {
+		fmt.Fprintf(sb, "\n%d QuotaIncrease(s) generated for %d namespace(s).\n", generated, len(migrations))
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const (
+	OutputFormatCSV      = "csv"
+	OutputFormatMarkdown = "markdown"
+)
+
+func NewReportCommand(so *SharedOptions) *cobra.Command {
+	opts := &ReportOptions{
+		SharedOptions:     so,
+		OnboardingCluster: clusters.New("onboarding"),
+	}
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Print a quota inventory of all managed namespaces in the onboarding cluster",
+		Long: `Walks the namespaces in the onboarding cluster and prints, for each namespace managed by this platform service, the quota definition,
+the effective hard limits, the sum of all QuotaIncreases, the usage and the utilization per resource.
+If --provider-name is set, only namespaces managed by the instance with this name are included. The other shared flags are ignored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawReportOptions struct {
+	QuotaDefinitions []string `json:"quota-definitions"`
+	Selector         string   `json:"selector"`
+	Output           string   `json:"output"`
+}
+
+type ReportOptions struct {
+	*SharedOptions
+	RawReportOptions
+	OnboardingCluster *clusters.Cluster
+
+	// fields filled in Complete()
+	LabelSelector labels.Selector
+}
+
+func (o *ReportOptions) AddFlags(cmd *cobra.Command) {
+	o.OnboardingCluster.RegisterConfigPathFlag(cmd.Flags())
+	cmd.Flags().StringSliceVar(&o.QuotaDefinitions, "quota-definition", nil, "Only include namespaces with one of the given quota definitions. Can be specified multiple times or as a comma-separated list.")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Only include namespaces matching this label selector, e.g. 'team=foo,env!=dev'.")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputFormatCSV, fmt.Sprintf("Output format. Valid values: %s, %s, %s.", OutputFormatCSV, OutputFormatJSON, OutputFormatMarkdown))
+}
+
+func (o *ReportOptions) Complete() error {
+	if !slices.Contains([]string{OutputFormatCSV, OutputFormatJSON, OutputFormatMarkdown}, o.Output) {
+		return fmt.Errorf("unsupported output format '%s'", o.Output)
+	}
+	sel, err := labels.Parse(o.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector '%s': %w", o.Selector, err)
+	}
+	o.LabelSelector = sel
+	if err := o.OnboardingCluster.InitializeRESTConfig(); err != nil {
+		return err
+	}
+	if err := o.OnboardingCluster.InitializeClient(providerscheme.InstallOperatorAPIsOnboarding(runtime.NewScheme())); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *ReportOptions) Run(ctx context.Context, out io.Writer) error {
+	cli := o.OnboardingCluster.Client()
+	nsList := &corev1.NamespaceList{}
+	if err := cli.List(ctx, nsList, client.MatchingLabelsSelector{Selector: o.LabelSelector}); err != nil {
+		return fmt.Errorf("error listing namespaces: %w", err)
+	}
+	qiList := &quotav1alpha1.QuotaIncreaseList{}
+	if err := cli.List(ctx, qiList); err != nil {
+		return fmt.Errorf("error listing QuotaIncreases: %w", err)
+	}
+	rqList := &corev1.ResourceQuotaList{}
+	if err := cli.List(ctx, rqList, client.HasLabels{quotav1alpha1.ManagedByLabel}); err != nil {
+		return fmt.Errorf("error listing ResourceQuotas: %w", err)
+	}
+
+	reports := quota.Report(o.ProviderName, o.QuotaDefinitions, pointerize(nsList.Items), pointerize(qiList.Items), pointerize(rqList.Items))
+
+	switch o.Output {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling report to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputFormatMarkdown:
+		return printReportMarkdown(out, reports)
+	}
+	return printReportCSV(out, reports)
+}
+
+var reportColumns = []string{"namespace", "quotaDefinition", "resource", "hard", "increases", "used", "utilization"}
+
+// reportRows flattens the report into one row per namespace and resource.
+// Namespaces without resources get a single row with empty resource columns.
+func reportRows(reports []quota.NamespaceReport) [][]string {
+	rows := [][]string{}
+	for _, nr := range reports {
+		if len(nr.Resources) == 0 {
+			rows = append(rows, []string{nr.Namespace, nr.QuotaDefinition, "", "", "", "", ""})
+			continue
+		}
+		for _, rr := range nr.Resources {
+			utilization := ""
+			if rr.Utilization != nil {
+				utilization = strconv.FormatFloat(*rr.Utilization, 'f', 1, 64)
+			}
+			rows = append(rows, []string{nr.Namespace, nr.QuotaDefinition, string(rr.Resource), rr.Hard.String(), rr.Increases.String(), rr.Used.String(), utilization})
+		}
+	}
+	return rows
+}
+
+// printReportCSV prints the report as CSV, including a header row.
+func printReportCSV(out io.Writer, reports []quota.NamespaceReport) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(reportColumns); err != nil {
+		return err
+	}
+	if err := w.WriteAll(reportRows(reports)); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+	return nil
+}
+
+// printReportMarkdown prints the report as a Markdown table.
+func printReportMarkdown(out io.Writer, reports []quota.NamespaceReport) error {
+	sb := &strings.Builder{}
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for _, c := range cells {
+			fmt.Fprintf(sb, " %s |", strings.ReplaceAll(c, "|", `\|`))
+		}
+		sb.WriteString("\n")
+	}
+	writeRow(reportColumns)
+	separator := make([]string, len(reportColumns))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeRow(separator)
+	for _, row := range reportRows(reports) {
+		writeRow(row)
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,6 +15,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,10 +32,13 @@ import (
 	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
 	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
 	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+	"github.com/openmcp-project/platform-service-quota/internal/server"
 )
 
 var setupLog logging.Logger
 
+var supportedControllers = []string{quota.ControllerName, quota.MCPControllerName}
+
 func NewRunCommand(so *SharedOptions) *cobra.Command {
 	opts := &RunOptions{
 		SharedOptions: so,
@@ -76,7 +82,31 @@ type RawRunOptions struct {
 	SecureMetrics        bool   `json:"metrics-secure"`
 	EnableHTTP2          bool   `json:"enable-http2"`
 
-	Controllers []string `json:"controllers"`
+	Mode              string        `json:"mode"`
+	Controllers       []string      `json:"controllers"`
+	MCPResyncInterval time.Duration `json:"mcp-resync-interval"`
+	ConfigRolloutRate float64       `json:"config-rollout-rate"`
+
+	// tuning flags
+	MaxConcurrentReconciles int           `json:"max-concurrent-reconciles"`
+	BackoffBaseDelay        time.Duration `json:"backoff-base-delay"`
+	BackoffMaxDelay         time.Duration `json:"backoff-max-delay"`
+	HistoryLimit            int           `json:"history-limit"`
+	OnboardingQPS           float32       `json:"onboarding-qps"`
+	OnboardingBurst         int           `json:"onboarding-burst"`
+	PlatformQPS             float32       `json:"platform-qps"`
+	PlatformBurst           int           `json:"platform-burst"`
+
+	// readiness flags
+	ReadinessErrorRateThreshold float64       `json:"readiness-error-rate-threshold"`
+	ReadinessErrorRateWindow    time.Duration `json:"readiness-error-rate-window"`
+
+	// API server flags
+	APIAddr     string `json:"api-bind-address"`
+	SecureAPI   bool   `json:"api-secure"`
+	APICertPath string `json:"api-cert-path"`
+	APICertName string `json:"api-cert-name"`
+	APICertKey  string `json:"api-cert-key"`
 }
 
 type RunOptions struct {
@@ -89,6 +119,7 @@ type RunOptions struct {
 	MetricsServerOptions metricsserver.Options
 	MetricsCertWatcher   *certwatcher.CertWatcher
 	WebhookCertWatcher   *certwatcher.CertWatcher
+	APICertWatcher       *certwatcher.CertWatcher
 	ProviderNamespace    string
 }
 
@@ -106,6 +137,33 @@ func (o *RunOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.MetricsCertName, "metrics-cert-name", "tls.crt", "The name of the metrics server certificate file.")
 	cmd.Flags().StringVar(&o.MetricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	cmd.Flags().BoolVar(&o.EnableHTTP2, "enable-http2", false, "If set, HTTP/2 will be enabled for the metrics and webhook servers")
+
+	// controller flags
+	cmd.Flags().StringVar(&o.Mode, "mode", quota.ModeEnforce, fmt.Sprintf("The mode of the controller. Supported values: %s, %s. In '%s' mode, the controller computes all changes, but does not perform them. Instead, they are logged, reported as events and exposed via metrics and the quota API.", quota.ModeEnforce, quota.ModeAudit, quota.ModeAudit))
+	cmd.Flags().StringSliceVar(&o.Controllers, "controllers", []string{quota.ControllerName}, fmt.Sprintf("List of controllers to run. Supported values: %s, %s. The '%s' controller is required for quota definitions targeting ManagedControlPlane clusters.", quota.ControllerName, quota.MCPControllerName, quota.MCPControllerName))
+	cmd.Flags().DurationVar(&o.MCPResyncInterval, "mcp-resync-interval", quota.DefaultMCPResyncInterval, "Interval after which the namespaces in ManagedControlPlane clusters are re-evaluated. Since namespaces and QuotaIncreases within these clusters are not watched, this is the maximum delay until changes to them are applied. Only relevant if the 'mcp-quota' controller is enabled.")
+	cmd.Flags().Float64Var(&o.ConfigRolloutRate, "config-rollout-rate", quota.DefaultConfigRolloutRate, "The number of namespaces per second which are enqueued for reconciliation after a QuotaServiceConfig change. Only namespaces affected by the change are enqueued. Set to 0 to enqueue them all at once.")
+
+	// tuning flags
+	cmd.Flags().IntVar(&o.MaxConcurrentReconciles, "max-concurrent-reconciles", quota.DefaultMaxConcurrentReconciles, "The number of namespaces (or ManagedControlPlane clusters for the 'mcp-quota' controller) which are reconciled in parallel.")
+	cmd.Flags().DurationVar(&o.BackoffBaseDelay, "backoff-base-delay", quota.DefaultBackoffBaseDelay, "The delay before a failed reconcile is retried for the first time. The delay doubles with every further failure of the same object.")
+	cmd.Flags().DurationVar(&o.BackoffMaxDelay, "backoff-max-delay", quota.DefaultBackoffMaxDelay, "The maximum delay between retries of a failed reconcile.")
+	cmd.Flags().IntVar(&o.HistoryLimit, "history-limit", quota.DefaultHistoryLimit, fmt.Sprintf("The number of changes of the generated ResourceQuota which are kept in the '%s' ConfigMap of each namespace. Set to 0 to disable the history ConfigMap.", quota.HistoryConfigMapName))
+	cmd.Flags().Float32Var(&o.OnboardingQPS, "onboarding-qps", 0, "The maximum number of requests per second to the onboarding cluster. Set to 0 to use the default of the client.")
+	cmd.Flags().IntVar(&o.OnboardingBurst, "onboarding-burst", 0, "The maximum burst of requests to the onboarding cluster. Set to 0 to use the default of the client.")
+	cmd.Flags().Float32Var(&o.PlatformQPS, "platform-qps", 0, "The maximum number of requests per second to the platform cluster. Set to 0 to use the default of the client.")
+	cmd.Flags().IntVar(&o.PlatformBurst, "platform-burst", 0, "The maximum burst of requests to the platform cluster. Set to 0 to use the default of the client.")
+
+	// readiness flags
+	cmd.Flags().Float64Var(&o.ReadinessErrorRateThreshold, "readiness-error-rate-threshold", quota.DefaultErrorRateThreshold, "The reconcile error rate (between 0 and 1) above which the controller is reported as not ready. Set to 1 to disable the check.")
+	cmd.Flags().DurationVar(&o.ReadinessErrorRateWindow, "readiness-error-rate-window", quota.DefaultErrorRateWindow, "The time window over which the reconcile error rate is computed for the readiness check.")
+
+	// API server flags
+	cmd.Flags().StringVar(&o.APIAddr, "api-bind-address", "0", "The address the read-only quota API binds to. Leave as 0 to disable the API.")
+	cmd.Flags().BoolVar(&o.SecureAPI, "api-secure", true, "If set, the quota API is served via HTTPS and protected with authn/authz, like the metrics endpoint. Use --api-secure=false to use HTTP without authn/authz instead.")
+	cmd.Flags().StringVar(&o.APICertPath, "api-cert-path", "", "The directory that contains the quota API server certificate. If not set, a self-signed certificate is generated.")
+	cmd.Flags().StringVar(&o.APICertName, "api-cert-name", "tls.crt", "The name of the quota API server certificate file.")
+	cmd.Flags().StringVar(&o.APICertKey, "api-cert-key", "tls.key", "The name of the quota API server key file.")
 }
 
 func (o *RunOptions) Complete(ctx context.Context) error {
@@ -120,6 +178,33 @@ func (o *RunOptions) Complete(ctx context.Context) error {
 	setupLog = o.Log.WithName("setup")
 	ctrl.SetLogger(o.Log.Logr())
 
+	for _, c := range o.Controllers {
+		if !slices.Contains(supportedControllers, c) {
+			return fmt.Errorf("unsupported controller '%s', supported controllers are: %s", c, strings.Join(supportedControllers, ", "))
+		}
+	}
+	if o.Mode != quota.ModeEnforce && o.Mode != quota.ModeAudit {
+		return fmt.Errorf("unsupported mode '%s', supported modes are: %s, %s", o.Mode, quota.ModeEnforce, quota.ModeAudit)
+	}
+	if o.ReadinessErrorRateThreshold < 0 || o.ReadinessErrorRateThreshold > 1 {
+		return fmt.Errorf("readiness error rate threshold must be between 0 and 1, got %v", o.ReadinessErrorRateThreshold)
+	}
+	if o.ConfigRolloutRate < 0 {
+		return fmt.Errorf("config rollout rate must not be negative, got %v", o.ConfigRolloutRate)
+	}
+	if o.ReadinessErrorRateWindow <= 0 {
+		return fmt.Errorf("readiness error rate window must be positive, got %s", o.ReadinessErrorRateWindow)
+	}
+	if o.MaxConcurrentReconciles < 1 {
+		return fmt.Errorf("max concurrent reconciles must be at least 1, got %d", o.MaxConcurrentReconciles)
+	}
+	if o.BackoffBaseDelay <= 0 || o.BackoffMaxDelay < o.BackoffBaseDelay {
+		return fmt.Errorf("backoff delays must be positive and the base delay must not exceed the max delay, got base delay %s and max delay %s", o.BackoffBaseDelay, o.BackoffMaxDelay)
+	}
+	if o.OnboardingQPS < 0 || o.OnboardingBurst < 0 || o.PlatformQPS < 0 || o.PlatformBurst < 0 {
+		return fmt.Errorf("client QPS and burst must not be negative")
+	}
+
 	// kubebuilder default stuff
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
@@ -199,10 +284,29 @@ func (o *RunOptions) Complete(ctx context.Context) error {
 		})
 	}
 
+	if o.apiEnabled() && len(o.APICertPath) > 0 {
+		setupLog.Info("Initializing API certificate watcher using provided certificates", "api-cert-path", o.APICertPath, "api-cert-name", o.APICertName, "api-cert-key", o.APICertKey)
+
+		var err error
+		o.APICertWatcher, err = certwatcher.New(
+			filepath.Join(o.APICertPath, o.APICertName),
+			filepath.Join(o.APICertPath, o.APICertKey),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to initialize API certificate watcher: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// apiEnabled returns true if the read-only quota API should be served.
+func (o *RunOptions) apiEnabled() bool {
+	return o.APIAddr != "" && o.APIAddr != "0"
+}
+
 func (o *RunOptions) Run(ctx context.Context) error {
+	setClientRateLimits(o.PlatformCluster.RESTConfig(), o.PlatformQPS, o.PlatformBurst)
 	if err := o.PlatformCluster.InitializeClient(providerscheme.InstallOperatorAPIsPlatform(runtime.NewScheme())); err != nil {
 		return err
 	}
@@ -256,6 +360,21 @@ func (o *RunOptions) Run(ctx context.Context) error {
 					Resources: []string{"resourcequotas", "resourcequotas/status"},
 					Verbs:     []string{"*"},
 				},
+				{
+					APIGroups: []string{""},
+					Resources: []string{"limitranges"},
+					Verbs:     []string{"*"},
+				},
+				{
+					APIGroups: []string{""},
+					Resources: []string{"configmaps"},
+					Verbs:     []string{"get", "create", "update", "patch"},
+				},
+				{
+					APIGroups: []string{""},
+					Resources: []string{"events"},
+					Verbs:     []string{"create", "patch"},
+				},
 			},
 		},
 	}
@@ -263,6 +382,13 @@ func (o *RunOptions) Run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("error creating/updating onboarding cluster: %w", err)
 	}
+	if o.OnboardingQPS > 0 || o.OnboardingBurst > 0 {
+		// the client has already been created by the cluster access manager, so it has to be re-created to pick up the rate limits
+		setClientRateLimits(onboardingCluster.RESTConfig(), o.OnboardingQPS, o.OnboardingBurst)
+		if err := onboardingCluster.InitializeClient(onboardingScheme); err != nil {
+			return fmt.Errorf("error re-creating onboarding cluster client: %w", err)
+		}
+	}
 
 	webhookServer := webhook.NewServer(webhook.Options{
 		TLSOpts: o.WebhookTLSOpts,
@@ -296,8 +422,44 @@ func (o *RunOptions) Run(ctx context.Context) error {
 	}
 
 	// setup Quota reconciler
-	if err := quota.NewQuotaController(o.PlatformCluster, onboardingCluster, o.ProviderName).SetupWithManager(mgr); err != nil {
-		return fmt.Errorf("unable to add Quota reconciler to manager: %w", err)
+	qc := quota.NewQuotaController(o.PlatformCluster, onboardingCluster, o.ProviderName)
+	qc.ConfigRolloutRate = o.ConfigRolloutRate
+	qc.MaxConcurrentReconciles = o.MaxConcurrentReconciles
+	qc.BackoffBaseDelay = o.BackoffBaseDelay
+	qc.BackoffMaxDelay = o.BackoffMaxDelay
+	qc.HistoryLimit = o.HistoryLimit
+	qc.ErrorRate = quota.NewErrorRateTracker(o.ReadinessErrorRateWindow, o.ReadinessErrorRateThreshold, quota.DefaultErrorRateMinSamples)
+	// the manager's cache belongs to the onboarding cluster and already contains the watched namespaces and QuotaIncreases
+	qc.OnboardingCache = mgr.GetCache()
+	if o.Mode == quota.ModeAudit {
+		setupLog.Info("Running in audit mode, changes to the clusters are only reported, but not performed")
+		qc.AuditLog = quota.NewAuditLog()
+		qc.Configs.IgnoreRolloutPolicy = true
+	}
+	if err := quota.SetupFieldIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+		return fmt.Errorf("unable to set up field indexes: %w", err)
+	}
+	if err := qc.Configs.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("unable to add QuotaServiceConfig watcher to manager: %w", err)
+	}
+	if slices.Contains(o.Controllers, quota.ControllerName) {
+		if err := qc.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to add Quota reconciler to manager: %w", err)
+		}
+		// staged rollouts only affect onboarding namespaces, which are handled by the Quota reconciler
+		// In audit mode, rollout policies are ignored, because updating the namespaces would require writes.
+		if qc.AuditLog == nil {
+			if err := quota.NewStagedRolloutController(qc).SetupWithManager(mgr); err != nil {
+				return fmt.Errorf("unable to add staged rollout reconciler to manager: %w", err)
+			}
+		}
+	}
+
+	// setup MCP Quota reconciler
+	if slices.Contains(o.Controllers, quota.MCPControllerName) {
+		if err := quota.NewMCPQuotaController(qc, o.ProviderNamespace, o.MCPResyncInterval).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to add MCP Quota reconciler to manager: %w", err)
+		}
 	}
 
 	if o.MetricsCertWatcher != nil {
@@ -307,6 +469,12 @@ func (o *RunOptions) Run(ctx context.Context) error {
 		}
 	}
 
+	if o.apiEnabled() {
+		if err := o.addAPIServer(mgr, qc.Configs, qc.AuditLog); err != nil {
+			return err
+		}
+	}
+
 	if o.WebhookCertWatcher != nil {
 		setupLog.Info("Adding webhook certificate watcher to manager")
 		if err := mgr.Add(o.WebhookCertWatcher); err != nil {
@@ -317,8 +485,17 @@ func (o *RunOptions) Run(ctx context.Context) error {
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to set up health check: %w", err)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		return fmt.Errorf("unable to set up ready check: %w", err)
+	// the pod is only ready if the config is valid, the caches of both clusters are synced and most reconciles succeed
+	readyzChecks := map[string]healthz.Checker{
+		"config":           qc.Configs.Check,
+		"platform-cache":   quota.CacheSyncCheck(o.PlatformCluster.Cluster().GetCache()),
+		"onboarding-cache": quota.CacheSyncCheck(mgr.GetCache()),
+		"reconcile-errors": qc.ErrorRate.Check,
+	}
+	for name, check := range readyzChecks {
+		if err := mgr.AddReadyzCheck(name, check); err != nil {
+			return fmt.Errorf("unable to set up ready check '%s': %w", name, err)
+		}
 	}
 
 	setupLog.Info("Starting manager")
@@ -328,3 +505,46 @@ func (o *RunOptions) Run(ctx context.Context) error {
 
 	return nil
 }
+
+// setClientRateLimits sets the client-side rate limits of the given REST config. Values of 0 keep the current setting.
+func setClientRateLimits(cfg *rest.Config, qps float32, burst int) {
+	if qps > 0 {
+		cfg.QPS = qps
+	}
+	if burst > 0 {
+		cfg.Burst = burst
+	}
+}
+
+// addAPIServer adds the read-only quota API server to the manager.
+// The API reads from the manager's cache, the active QuotaServiceConfig is taken from the given provider.
+// The audit log is only set in audit mode.
+func (o *RunOptions) addAPIServer(mgr ctrl.Manager, cfgProvider server.ConfigProvider, auditLog *quota.AuditLog) error {
+	apiServer := &server.Server{
+		BindAddress:   o.APIAddr,
+		SecureServing: o.SecureAPI,
+		TLSOpts:       o.TLSOpts,
+		CertWatcher:   o.APICertWatcher,
+		Handler:       server.NewHandler(mgr.GetClient(), o.ProviderName, cfgProvider).WithAuditLog(auditLog),
+		Log:           o.Log.WithName("api"),
+	}
+	if o.SecureAPI {
+		// same authn/authz as for the metrics endpoint, the API paths have to be allowed via 'nonResourceURLs' in RBAC
+		filter, err := filters.WithAuthenticationAndAuthorization(mgr.GetConfig(), mgr.GetHTTPClient())
+		if err != nil {
+			return fmt.Errorf("unable to create authn/authz filter for API server: %w", err)
+		}
+		apiServer.Filter = filter
+	}
+	if o.APICertWatcher != nil {
+		setupLog.Info("Adding API certificate watcher to manager")
+		if err := mgr.Add(o.APICertWatcher); err != nil {
+			return fmt.Errorf("unable to add API certificate watcher to manager: %w", err)
+		}
+	}
+	setupLog.Info("Adding API server to manager", "address", o.APIAddr)
+	if err := mgr.Add(apiServer); err != nil {
+		return fmt.Errorf("unable to add API server to manager: %w", err)
+	}
+	return nil
+}
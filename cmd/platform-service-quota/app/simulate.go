@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const (
+	OutputFormatText = "text"
+	OutputFormatYAML = "yaml"
+	OutputFormatJSON = "json"
+)
+
+func NewSimulateCommand(so *SharedOptions) *cobra.Command {
+	opts := &SimulateOptions{
+		SharedOptions: so,
+	}
+	cmd := &cobra.Command{
+		Use:   "simulate <path>...",
+		Short: "Preview the ResourceQuotas computed for namespaces, without cluster access",
+		Long: `Reads a QuotaServiceConfig, Namespaces and QuotaIncreases from the given files and directories and prints, per namespace,
+the matching quota definition, the resulting ResourceQuota (and LimitRange) and the effects of the QuotaIncreases.
+Directories are read recursively, files may contain multiple YAML documents.
+If multiple QuotaServiceConfigs are found, the one named after the provider name is used.
+The shared flags apart from --provider-name are ignored.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Paths = args
+			if err := opts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	opts.AddFlags(cmd)
+
+	return cmd
+}
+
+type RawSimulateOptions struct {
+	Paths  []string `json:"paths"`
+	Target string   `json:"target"`
+	Output string   `json:"output"`
+}
+
+type SimulateOptions struct {
+	*SharedOptions
+	RawSimulateOptions
+}
+
+func (o *SimulateOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Target, "target", string(quotav1alpha1.TARGET_ONBOARDING), fmt.Sprintf("Only quota definitions with this target are evaluated. Valid values: %s, %s.", quotav1alpha1.TARGET_ONBOARDING, quotav1alpha1.TARGET_MCP))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputFormatText, fmt.Sprintf("Output format. Valid values: %s, %s, %s.", OutputFormatText, OutputFormatYAML, OutputFormatJSON))
+}
+
+func (o *SimulateOptions) Complete() error {
+	if !slices.Contains(quotav1alpha1.SUPPORTED_TARGETS, quotav1alpha1.QuotaTarget(o.Target)) {
+		return fmt.Errorf("unsupported target '%s'", o.Target)
+	}
+	if !slices.Contains([]string{OutputFormatText, OutputFormatYAML, OutputFormatJSON}, o.Output) {
+		return fmt.Errorf("unsupported output format '%s'", o.Output)
+	}
+	return nil
+}
+
+func (o *SimulateOptions) Run(ctx context.Context, out io.Writer) error {
+	scheme := providerscheme.InstallOperatorAPIsOnboarding(providerscheme.InstallOperatorAPIsPlatform(runtime.NewScheme()))
+	docs, err := readDocuments(o.Paths...)
+	if err != nil {
+		return err
+	}
+	objs, err := decodeDocuments(scheme, docs)
+	if err != nil {
+		return err
+	}
+
+	cfgs := []*quotav1alpha1.QuotaServiceConfig{}
+	namespaces := []*corev1.Namespace{}
+	qis := []*quotav1alpha1.QuotaIncrease{}
+	for _, obj := range objs {
+		switch typed := obj.Object.(type) {
+		case *quotav1alpha1.QuotaServiceConfig:
+			cfgs = append(cfgs, typed)
+		case *corev1.Namespace:
+			namespaces = append(namespaces, typed)
+		case *quotav1alpha1.QuotaIncrease:
+			qis = append(qis, typed)
+		default:
+			// other objects are ignored, so that whole directories of manifests can be used as input
+		}
+	}
+	cfg, err := selectConfig(cfgs, o.ProviderName)
+	if err != nil {
+		return err
+	}
+	providerName := o.ProviderName
+	if providerName == "" {
+		providerName = cfg.Name
+	}
+
+	// the computation logs are not relevant for the output
+	sims, err := quota.Simulate(logging.NewContextWithDiscard(ctx), cfg, providerName, quotav1alpha1.QuotaTarget(o.Target), namespaces, qis)
+	if err != nil {
+		return err
+	}
+
+	switch o.Output {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(sims, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling simulation result to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case OutputFormatYAML:
+		data, err := yaml.Marshal(sims)
+		if err != nil {
+			return fmt.Errorf("error marshalling simulation result to YAML: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	return printSimulation(out, sims)
+}
+
+// selectConfig returns the only QuotaServiceConfig from the list or, if there are multiple, the one with the given name.
+func selectConfig(cfgs []*quotav1alpha1.QuotaServiceConfig, name string) (*quotav1alpha1.QuotaServiceConfig, error) {
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("no QuotaServiceConfig found")
+	}
+	if len(cfgs) == 1 && (name == "" || cfgs[0].Name == name) {
+		return cfgs[0], nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("found %d QuotaServiceConfigs, use --provider-name to select one", len(cfgs))
+	}
+	for _, cfg := range cfgs {
+		if cfg.Name == name {
+			return cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("no QuotaServiceConfig with name '%s' found", name)
+}
+
+// printSimulation prints the simulation result in a human-readable format.
+func printSimulation(out io.Writer, sims []quota.NamespaceSimulation) error {
+	sb := &strings.Builder{}
+	for i, sim := range sims {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "Namespace: %s\n", sim.Namespace)
+		if sim.QuotaDefinition == "" {
+			sb.WriteString("  no matching quota definition\n")
+			continue
+		}
+		fmt.Fprintf(sb, "  Quota definition: %s\n", sim.QuotaDefinition)
+		sb.WriteString("  ResourceQuota:\n")
+		writeResourceList(sb, "    ", sim.ResourceQuota.Spec.Hard)
+		if sim.LimitRange != nil {
+			sb.WriteString("  LimitRange:\n")
+			for _, item := range sim.LimitRange.Spec.Limits {
+				fmt.Fprintf(sb, "    %s:\n", item.Type)
+				for _, field := range []struct {
+					name string
+					data corev1.ResourceList
+				}{
+					{"max", item.Max},
+					{"min", item.Min},
+					{"default", item.Default},
+					{"defaultRequest", item.DefaultRequest},
+					{"maxLimitRequestRatio", item.MaxLimitRequestRatio},
+				} {
+					if len(field.data) > 0 {
+						fmt.Fprintf(sb, "      %s:\n", field.name)
+						writeResourceList(sb, "        ", field.data)
+					}
+				}
+			}
+		}
+		if len(sim.QuotaIncreases) > 0 {
+			sb.WriteString("  QuotaIncreases:\n")
+			for _, qi := range sim.QuotaIncreases {
+				switch {
+				case qi.Deleted && qi.Rejected != "":
+					fmt.Fprintf(sb, "    %s: <deleted, rejected: %s>\n", qi.Name, qi.Rejected)
+				case qi.Deleted:
+					fmt.Fprintf(sb, "    %s: <deleted, ineffective>\n", qi.Name)
+				case qi.EffectAnnotation == "":
+					fmt.Fprintf(sb, "    %s: <no effect>\n", qi.Name)
+				default:
+					fmt.Fprintf(sb, "    %s: %s\n", qi.Name, qi.EffectAnnotation)
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+// writeResourceList writes the given resource list with one resource per line, sorted by resource name.
+func writeResourceList(sb *strings.Builder, indent string, data corev1.ResourceList) {
+	for _, res := range sets.List(sets.KeySet(data)) {
+		q := data[res]
+		fmt.Fprintf(sb, "%s%s: %s\n", indent, res, q.String())
+	}
+}
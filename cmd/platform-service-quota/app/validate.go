@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+func NewValidateCommand(so *SharedOptions) *cobra.Command {
+	opts := &ValidateOptions{
+		SharedOptions: so,
+	}
+	cmd := &cobra.Command{
+		Use:   "validate <path>...",
+		Short: "Validate QuotaServiceConfig files",
+		Long: `Reads QuotaServiceConfigs from the given files and directories and validates them the same way the controller does.
+Directories are read recursively, files may contain multiple YAML or JSON documents. Documents of other kinds are skipped.
+Unknown or duplicate fields are reported as errors, too. Each error is prefixed with the file and line it refers to.
+The command exits with a non-zero exit code if any error has been found, so it can be used as a pre-commit hook or in CI pipelines.
+The shared flags are ignored.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Paths = args
+			// validation errors are not usage errors
+			cmd.SilenceUsage = true
+			return opts.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+
+	return cmd
+}
+
+type RawValidateOptions struct {
+	Paths []string `json:"paths"`
+}
+
+type ValidateOptions struct {
+	*SharedOptions
+	RawValidateOptions
+}
+
+var strictErrorFieldRegex = regexp.MustCompile(`field "([^"]+)"`)
+
+// validationError is a validation error with a hint to its origin.
+type validationError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e validationError) String() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err.Error())
+}
+
+func (o *ValidateOptions) Run(_ context.Context, out io.Writer) error {
+	scheme := providerscheme.InstallOperatorAPIsPlatform(runtime.NewScheme())
+	// the strict decoder reports unknown and duplicate fields in addition to returning the decoded object
+	decoder := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDeserializer()
+
+	docs, err := readDocuments(o.Paths...)
+	if err != nil {
+		return err
+	}
+
+	errs := []validationError{}
+	validated := 0
+	for _, doc := range docs {
+		obj, _, err := decoder.Decode(doc.Data, nil, nil)
+		if err != nil && !runtime.IsStrictDecodingError(err) {
+			if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+				// not a QuotaServiceConfig
+				continue
+			}
+			errs = append(errs, validationError{File: doc.File, Line: doc.Line, Err: fmt.Errorf("unable to decode document: %w", err)})
+			continue
+		}
+		cfg, ok := obj.(*quotav1alpha1.QuotaServiceConfig)
+		if !ok {
+			continue
+		}
+		validated++
+
+		var root yaml.Node
+		if err := yaml.Unmarshal(doc.Data, &root); err != nil {
+			// cannot happen for documents that have been decoded successfully, fall back to the document's line
+			root = yaml.Node{}
+		}
+		if sdErr, ok := runtime.AsStrictDecodingError(err); ok {
+			for _, sErr := range sdErr.Errors() {
+				// the messages look like 'unknown field "spec.quotas[0].foo"'
+				line := 1
+				if match := strictErrorFieldRegex.FindStringSubmatch(sErr.Error()); match != nil {
+					line = lineOfField(&root, match[1])
+				}
+				errs = append(errs, validationError{File: doc.File, Line: doc.Line + line - 1, Err: sErr})
+			}
+		}
+		for _, fErr := range cfg.Spec.ValidateRaw() {
+			errs = append(errs, validationError{File: doc.File, Line: doc.Line + lineOfField(&root, fErr.Field) - 1, Err: fErr})
+		}
+	}
+
+	sb := &strings.Builder{}
+	for _, e := range errs {
+		fmt.Fprintln(sb, e.String())
+	}
+	if _, err := io.WriteString(out, sb.String()); err != nil {
+		return err
+	}
+	if validated == 0 {
+		return fmt.Errorf("no QuotaServiceConfig found")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d error(s) in %d QuotaServiceConfig(s)", len(errs), validated)
+	}
+	_, err = fmt.Fprintf(out, "%d QuotaServiceConfig(s) valid\n", validated)
+	return err
+}
+
+// lineOfField returns the line (starting at 1, relative to the document) of the field with the given path.
+// If the field does not exist in the document, e.g. because it is missing, the line of the closest existing parent is returned.
+func lineOfField(root *yaml.Node, path string) int {
+	line := 1
+	cur := root
+	if cur.Kind == yaml.DocumentNode && len(cur.Content) > 0 {
+		cur = cur.Content[0]
+	}
+	for _, segment := range splitFieldPath(path) {
+		if cur.Kind == yaml.AliasNode {
+			cur = cur.Alias
+		}
+		var next *yaml.Node
+		switch cur.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(cur.Content); i += 2 {
+				if cur.Content[i].Value == segment {
+					line = cur.Content[i].Line
+					next = cur.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if idx, err := strconv.Atoi(segment); err == nil && idx >= 0 && idx < len(cur.Content) {
+				next = cur.Content[idx]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		cur = next
+	}
+	return line
+}
+
+// splitFieldPath splits a field path as returned by field.Path.String() into its segments.
+// Example: 'spec.quotas[0].template.spec.hard[count/secrets]' => ['spec', 'quotas', '0', 'template', 'spec', 'hard', 'count/secrets']
+func splitFieldPath(path string) []string {
+	res := []string{}
+	cur := &strings.Builder{}
+	flush := func() {
+		if cur.Len() > 0 {
+			res = append(res, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				end = len(path) - i
+			}
+			res = append(res, path[i+1:i+end])
+			i += end
+		default:
+			cur.WriteByte(path[i])
+		}
+	}
+	flush()
+	return res
+}
@@ -0,0 +1,250 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// adoptionAction is what has been done with an unmanaged ResourceQuota. Used as metric label.
+type adoptionAction string
+
+const (
+	// adoptionActionAdopted means that the unmanaged ResourceQuota has been taken over as the generated ResourceQuota.
+	adoptionActionAdopted adoptionAction = "adopted"
+	// adoptionActionConverted means that the unmanaged ResourceQuota has been converted into a QuotaIncrease.
+	adoptionActionConverted adoptionAction = "converted"
+	// adoptionActionDeleted means that the unmanaged ResourceQuota has been deleted.
+	adoptionActionDeleted adoptionAction = "deleted"
+)
+
+// resourceQuotaAdoption is the outcome of the adoption policy of a quota definition for a namespace.
+type resourceQuotaAdoption struct {
+	// adopted is the unmanaged ResourceQuota which has the name of the generated one and is taken over when the generated one is applied, if any.
+	adopted *corev1.ResourceQuota
+	// obsolete are the unmanaged ResourceQuotas which have to be deleted once the generated ResourceQuota has been applied.
+	obsolete []corev1.ResourceQuota
+	// quotaIncrease is the name of the QuotaIncrease which preserves the limits of the converted ResourceQuotas, if one was required.
+	quotaIncrease string
+}
+
+// isUnmanagedResourceQuota returns true if the ResourceQuota has not been created by any instance of the Quota Controller.
+func isUnmanagedResourceQuota(rq *corev1.ResourceQuota) bool {
+	return !ctrlutils.HasLabel(rq, quotav1alpha1.ManagedByLabel)
+}
+
+// AdoptedQuotaIncreaseName is the name of the QuotaIncrease into which the unmanaged ResourceQuotas of a namespace are converted by the 'merge-as-increase' adoption policy.
+// If a QuotaIncrease with this name already exists in the namespace, a numeric suffix is appended.
+const AdoptedQuotaIncreaseName = "adopted"
+
+// adoptResourceQuotas evaluates the adoption policy of the quota definition for the unmanaged ResourceQuotas in the namespace.
+// For the 'merge-as-increase' policy, the QuotaIncrease is created here and added to the given list, so that it is taken into account when computing the ResourceQuota.
+// Deleting the unmanaged ResourceQuotas is left to finishAdoption, which must be called after the generated ResourceQuota has been applied, so that the namespace is never without quota.
+func (r *QuotaController) adoptResourceQuotas(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList) (*resourceQuotaAdoption, error) {
+	log := logging.FromContextOrPanic(ctx)
+	policy := qdef.GetAdoptionPolicy()
+
+	rqs := &corev1.ResourceQuotaList{}
+	if err := r.reader(tgt).List(ctx, rqs, client.InNamespace(ns.Name)); err != nil {
+		return nil, fmt.Errorf("error listing ResourceQuotas: %w", err)
+	}
+
+	res := &resourceQuotaAdoption{}
+	convertible := []*corev1.ResourceQuota{}
+	for _, rq := range rqs.Items {
+		if !isUnmanagedResourceQuota(&rq) {
+			continue
+		}
+		if rq.Name == qdef.Name {
+			if policy == quotav1alpha1.ADOPTION_IGNORE {
+				msg := fmt.Sprintf("ResourceQuota '%s' has not been created by the Quota Controller and is not taken over, because the adoption policy is '%s'", rq.Name, policy)
+				r.recordEvent(ctx, tgt, ns, corev1.EventTypeWarning, "ResourceQuotaNotAdopted", msg)
+				return nil, NewConfigError(fmt.Errorf("%s", msg))
+			}
+			res.adopted = &rq
+			if policy != quotav1alpha1.ADOPTION_MERGE_AS_INCREASE {
+				continue
+			}
+		}
+
+		switch policy {
+		case quotav1alpha1.ADOPTION_IGNORE, quotav1alpha1.ADOPTION_ADOPT_BY_NAME:
+			log.Debug("Ignoring unmanaged ResourceQuota", "resourceQuota", rq.Name, "adoptionPolicy", policy)
+		case quotav1alpha1.ADOPTION_REPLACE:
+			res.obsolete = append(res.obsolete, rq)
+		case quotav1alpha1.ADOPTION_MERGE_AS_INCREASE:
+			if len(rq.Spec.Scopes) > 0 || rq.Spec.ScopeSelector != nil {
+				// QuotaIncreases apply to all objects in the namespace, converting a scoped quota would change its meaning
+				log.Info("Unmanaged ResourceQuota has scopes and can't be converted into a QuotaIncrease, ignoring it", "resourceQuota", rq.Name)
+				continue
+			}
+			convertible = append(convertible, &rq)
+			if rq.Name != qdef.Name {
+				res.obsolete = append(res.obsolete, rq)
+			}
+		}
+	}
+	if len(convertible) > 0 {
+		if err := r.convertResourceQuotas(ctx, tgt, ns, qdef, convertible, qis, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// convertResourceQuotas creates a single QuotaIncrease which preserves the limits enforced by the given ResourceQuotas together and adds it to the given list, see preserveHardLimits.
+// In singular mode, the namespace is labeled to reference the QuotaIncrease.
+// Nothing is created if the generated ResourceQuota already preserves the limits, e.g. because the QuotaIncrease has been created in a previous reconcile, in which deleting the ResourceQuotas failed.
+// If the QuotaIncrease would be rejected due to the limits of the quota definition, it is not created and the ResourceQuotas are kept.
+func (r *QuotaController) convertResourceQuotas(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, rqs []*corev1.ResourceQuota, qis *quotav1alpha1.QuotaIncreaseList, adoption *resourceQuotaAdoption) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	names := make([]string, 0, len(rqs))
+	for _, rq := range rqs {
+		names = append(names, rq.Name)
+	}
+	slices.Sort(names)
+	// the limit computations are not relevant for the logs
+	p := r.preserveHardLimits(logging.NewContextWithDiscard(ctx), ns, qdef, qis, uniqueQuotaIncreaseName(qis, AdoptedQuotaIncreaseName), names, effectiveHardLimits(rqs))
+	if p.quotaIncrease == nil {
+		log.Debug("Generated ResourceQuota preserves the limits of the unmanaged ResourceQuotas", "resourceQuotas", names)
+		return nil
+	}
+	qi := p.quotaIncrease
+	if p.rejected != "" {
+		msg := fmt.Sprintf("ResourceQuotas '%s' are not converted, because the QuotaIncrease '%s' which preserves their limits would be rejected: %s", strings.Join(names, "', '"), qi.Name, p.rejected)
+		log.Info(msg, "adoptionPolicy", qdef.GetAdoptionPolicy())
+		r.recordEvent(ctx, tgt, ns, corev1.EventTypeWarning, "ResourceQuotaNotAdopted", msg)
+		adoption.obsolete = nil
+		return nil
+	}
+
+	change := &AuditChange{Kind: "QuotaIncrease", Name: qi.Name, Action: AuditActionCreate, Details: fmt.Sprintf("converted from ResourceQuotas '%s', hard: %s", strings.Join(names, "', '"), effectAsString(qi.Spec.Hard))}
+	if err := r.write(ctx, change, func() error {
+		if err := tgt.Client().Create(ctx, qi); err != nil {
+			return fmt.Errorf("error creating QuotaIncrease '%s' from ResourceQuotas: %w", qi.Name, err)
+		}
+		for _, name := range names {
+			r.recordAdoption(ctx, tgt, ns, qdef, name, adoptionActionConverted, fmt.Sprintf("Converted ResourceQuota '%s' into QuotaIncrease '%s'", name, qi.Name))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	qis.Items = append(qis.Items, *qi)
+	adoption.quotaIncrease = qi.Name
+
+	if p.useQuotaIncrease {
+		// the QuotaIncrease replaces the one referenced so far, otherwise it would not have any effect
+		change := &AuditChange{Kind: "Namespace", Name: ns.Name, Action: AuditActionUpdate, Details: fmt.Sprintf("label %s=%s", quotav1alpha1.SingularQuotaIncreaseLabel, qi.Name)}
+		if err := r.write(ctx, change, func() error {
+			patch := client.RawPatch(types.MergePatchType, fmt.Appendf(nil, `{"metadata":{"labels":{%q:%q}}}`, quotav1alpha1.SingularQuotaIncreaseLabel, qi.Name))
+			if err := tgt.Client().Patch(ctx, ns.DeepCopy(), patch); err != nil {
+				return fmt.Errorf("error labeling namespace to use QuotaIncrease '%s': %w", qi.Name, err)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if ns.Labels == nil {
+			ns.Labels = map[string]string{}
+		}
+		ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = qi.Name
+	}
+	return nil
+}
+
+// finishAdoption deletes the unmanaged ResourceQuotas which have been replaced by the generated ResourceQuota and finishes the adoption of a ResourceQuota with the name of the generated one, see removeForeignLimits.
+// It must only be called after the given generated ResourceQuota has been applied.
+func (r *QuotaController) finishAdoption(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, rq *corev1.ResourceQuota, adoption *resourceQuotaAdoption) error {
+	if adoption.adopted != nil {
+		if err := r.removeForeignLimits(ctx, tgt, adoption.adopted, rq); err != nil {
+			return err
+		}
+		if !r.auditing() {
+			r.recordAdoption(ctx, tgt, ns, qdef, adoption.adopted.Name, adoptionActionAdopted, fmt.Sprintf("Took over pre-existing ResourceQuota '%s'", adoption.adopted.Name))
+		}
+	}
+	for _, rq := range adoption.obsolete {
+		reason := fmt.Sprintf("replaced by ResourceQuota '%s'", qdef.Name)
+		if adoption.quotaIncrease != "" {
+			reason = fmt.Sprintf("converted into QuotaIncrease '%s'", adoption.quotaIncrease)
+		} else if qdef.GetAdoptionPolicy() == quotav1alpha1.ADOPTION_MERGE_AS_INCREASE {
+			reason = fmt.Sprintf("limits preserved by ResourceQuota '%s'", qdef.Name)
+		}
+		change := &AuditChange{Kind: "ResourceQuota", Name: rq.Name, Action: AuditActionDelete, Details: reason}
+		if err := r.write(ctx, change, func() error {
+			if err := tgt.Client().Delete(ctx, &rq); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("error deleting unmanaged ResourceQuota '%s': %w", rq.Name, err)
+			}
+			r.recordAdoption(ctx, tgt, ns, qdef, rq.Name, adoptionActionDeleted, fmt.Sprintf("Deleted pre-existing ResourceQuota '%s', %s", rq.Name, reason))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAdoption reports what has been done with an unmanaged ResourceQuota via log, event on the namespace and metric.
+func (r *QuotaController) recordAdoption(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, rqName string, action adoptionAction, message string) {
+	log := logging.FromContextOrPanic(ctx)
+	policy := qdef.GetAdoptionPolicy()
+	log.Info(message, "resourceQuota", rqName, "adoptionPolicy", policy, "action", action)
+	r.recordEvent(ctx, tgt, ns, corev1.EventTypeNormal, "ResourceQuotaAdopted", fmt.Sprintf("%s (adoption policy: %s)", message, policy))
+	resourceQuotaAdoptionsTotal.WithLabelValues(qdef.Name, string(policy), string(action)).Inc()
+}
+
+// removeForeignLimits removes the hard limits and scopes of the adopted ResourceQuota which are not part of the generated ResourceQuota.
+// Server-side apply only removes fields which have been owned by the applying field manager, so without this, the limits set by the previous manager of the ResourceQuota would still be enforced.
+func (r *QuotaController) removeForeignLimits(ctx context.Context, tgt *clusters.Cluster, adopted, rq *corev1.ResourceQuota) error {
+	fields := []string{}
+	hard := map[corev1.ResourceName]any{}
+	for _, name := range slices.Sorted(maps.Keys(adopted.Spec.Hard)) {
+		if _, ok := rq.Spec.Hard[name]; !ok {
+			hard[name] = nil
+			fields = append(fields, "spec.hard."+string(name))
+		}
+	}
+	spec := map[string]any{}
+	if len(hard) > 0 {
+		spec["hard"] = hard
+	}
+	if len(adopted.Spec.Scopes) > 0 && len(rq.Spec.Scopes) == 0 {
+		spec["scopes"] = nil
+		fields = append(fields, "spec.scopes")
+	}
+	if adopted.Spec.ScopeSelector != nil && rq.Spec.ScopeSelector == nil {
+		spec["scopeSelector"] = nil
+		fields = append(fields, "spec.scopeSelector")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(map[string]any{"spec": spec})
+	if err != nil {
+		return fmt.Errorf("error marshalling patch for ResourceQuota '%s': %w", adopted.Name, err)
+	}
+
+	change := &AuditChange{Kind: "ResourceQuota", Name: adopted.Name, Action: AuditActionUpdate, Details: fmt.Sprintf("remove fields not set by the quota definition: %s", strings.Join(fields, ", "))}
+	return r.write(ctx, change, func() error {
+		logging.FromContextOrPanic(ctx).Info("Removing fields of adopted ResourceQuota which are not set by the quota definition", "resourceQuota", adopted.Name, "fields", fields)
+		if err := tgt.Client().Patch(ctx, rq.DeepCopy(), client.RawPatch(types.MergePatchType, data)); err != nil {
+			return fmt.Errorf("error removing fields from adopted ResourceQuota '%s': %w", adopted.Name, err)
+		}
+		return nil
+	})
+}
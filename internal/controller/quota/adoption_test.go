@@ -0,0 +1,263 @@
+package quota_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("ResourceQuota Adoption", func() {
+
+	var env *testutils.ComplexEnvironment
+	var ns *corev1.Namespace
+
+	// createUnmanagedResourceQuota creates a ResourceQuota in the test namespace, as if it had been created manually before the Quota Controller was enabled.
+	createUnmanagedResourceQuota := func(name string, hard corev1.ResourceList) {
+		rq := &corev1.ResourceQuota{}
+		rq.SetName(name)
+		rq.SetNamespace(ns.Name)
+		rq.Spec.Hard = hard
+		ExpectWithOffset(1, env.Client(onboardingCluster).Create(env.Ctx, rq)).To(Succeed())
+	}
+
+	getResourceQuota := func(name string) (*corev1.ResourceQuota, error) {
+		rq := &corev1.ResourceQuota{}
+		err := env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: name}, rq)
+		return rq, err
+	}
+
+	setAdoptionPolicy := func(policy quotav1alpha1.AdoptionPolicy) {
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("all").AdoptionPolicy = policy
+		})
+	}
+
+	// adoptionEvents returns the messages of all events which report the handling of unmanaged ResourceQuotas.
+	adoptionEvents := func() []string {
+		evs := &corev1.EventList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, evs)).To(Succeed())
+		res := []string{}
+		for _, ev := range evs.Items {
+			if ev.Reason == "ResourceQuotaAdopted" || ev.Reason == "ResourceQuotaNotAdopted" {
+				res = append(res, ev.Message)
+			}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		ns = &corev1.Namespace{}
+		ns.SetName("ns-normal")
+		createUnmanagedResourceQuota("all", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("10")})
+		createUnmanagedResourceQuota("legacy", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("2"), "pods": resource.MustParse("10")})
+	})
+
+	It("should take over an unmanaged ResourceQuota with the same name by default", func() {
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+		Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+		Expect(rq.Annotations).ToNot(HaveKey(quotav1alpha1.DriftAnnotation))
+
+		legacy, err := getResourceQuota("legacy")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(legacy.Labels).ToNot(HaveKey(quotav1alpha1.ManagedByLabel))
+
+		Expect(adoptionEvents()).To(ConsistOf(ContainSubstring("Took over pre-existing ResourceQuota 'all'")))
+
+		// the adoption is only recorded once
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(adoptionEvents()).To(HaveLen(1))
+	})
+
+	It("should remove limits and scopes of a taken over ResourceQuota which are not part of the quota definition", func() {
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		rq.Spec.Hard["pods"] = resource.MustParse("5")
+		rq.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotTerminating}
+		Expect(env.Client(onboardingCluster).Update(env.Ctx, rq)).To(Succeed())
+
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		rq, err = getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+		Expect(rq.Spec.Hard).To(And(HaveLen(1), HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchNumericQuantity(3))))
+		Expect(rq.Spec.Scopes).To(BeEmpty())
+		Expect(adoptionEvents()).To(ConsistOf(ContainSubstring("Took over pre-existing ResourceQuota 'all'")))
+	})
+
+	It("should only record the removal of foreign limits in audit mode", func() {
+		qc := env.Reconciler(rec).(*quotacontroller.QuotaController)
+		qc.AuditLog = quotacontroller.NewAuditLog()
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		rq.Spec.Hard["pods"] = resource.MustParse("5")
+		Expect(env.Client(onboardingCluster).Update(env.Ctx, rq)).To(Succeed())
+
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		rq, err = getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Spec.Hard).To(HaveKey(corev1.ResourceName("pods")))
+		Expect(qc.AuditLog.Changes()).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+			"Kind":    Equal("ResourceQuota"),
+			"Name":    Equal("all"),
+			"Action":  Equal(quotacontroller.AuditActionUpdate),
+			"Details": Equal("remove fields not set by the quota definition: spec.hard.pods"),
+		})))
+	})
+
+	It("should not touch unmanaged ResourceQuotas with the 'ignore' policy", func() {
+		setAdoptionPolicy(quotav1alpha1.ADOPTION_IGNORE)
+
+		_, err := env.Reconciler(rec).Reconcile(env.Ctx, testutils.RequestFromObject(ns))
+		Expect(err).To(HaveOccurred())
+		Expect(quotacontroller.ErrorClassOf(err)).To(Equal(quotacontroller.ErrorClassConfig))
+		Expect(adoptionEvents()).To(ConsistOf(ContainSubstring("is not taken over")))
+
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Labels).ToNot(HaveKey(quotav1alpha1.ManagedByLabel))
+		Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("10")))
+
+		// without a name conflict, the generated ResourceQuota is created next to the unmanaged ones
+		Expect(env.Client(onboardingCluster).Delete(env.Ctx, rq)).To(Succeed())
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		rq, err = getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+		_, err = getResourceQuota("legacy")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should delete other unmanaged ResourceQuotas with the 'replace' policy", func() {
+		setAdoptionPolicy(quotav1alpha1.ADOPTION_REPLACE)
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+		_, err = getResourceQuota("legacy")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		Expect(adoptionEvents()).To(ConsistOf(
+			ContainSubstring("Took over pre-existing ResourceQuota 'all'"),
+			ContainSubstring("Deleted pre-existing ResourceQuota 'legacy', replaced by ResourceQuota 'all'"),
+		))
+	})
+
+	DescribeTable("should convert unmanaged ResourceQuotas into a single QuotaIncrease with the 'merge-as-increase' policy",
+		func(mode quotav1alpha1.QuotaIncreaseOperatingMode, expectedIncrease string) {
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				qdef := cfg.Spec.GetQuotaDefinitionForName("all")
+				qdef.AdoptionPolicy = quotav1alpha1.ADOPTION_MERGE_AS_INCREASE
+				qdef.Mode = mode
+			})
+			// the unmanaged quotas together enforce the lowest of their limits
+			legacy, err := getResourceQuota("legacy")
+			Expect(err).ToNot(HaveOccurred())
+			legacy.Spec.Hard["count/serviceaccounts"] = resource.MustParse("8")
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, legacy)).To(Succeed())
+			scoped := &corev1.ResourceQuota{}
+			scoped.SetName("scoped")
+			scoped.SetNamespace(ns.Name)
+			scoped.Spec.Hard = corev1.ResourceList{"pods": resource.MustParse("0")}
+			scoped.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+			Expect(env.Client(onboardingCluster).Create(env.Ctx, scoped)).To(Succeed())
+
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			qis := &quotav1alpha1.QuotaIncreaseList{}
+			Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(qis.Items).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+					"Name":        Equal(quotacontroller.AdoptedQuotaIncreaseName),
+					"Annotations": HaveKeyWithValue(quotav1alpha1.AdoptedFromAnnotation, "all,legacy"),
+				}),
+				"Spec": MatchFields(IgnoreExtras, Fields{
+					// resources which are not part of the quota definition are not preserved
+					"Hard": And(HaveLen(1), HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchQuantity(resource.MustParse(expectedIncrease)))),
+				}),
+			})))
+
+			nsObj := &corev1.Namespace{}
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), nsObj)).To(Succeed())
+			if mode == quotav1alpha1.SINGULAR {
+				Expect(nsObj.Labels).To(HaveKeyWithValue(quotav1alpha1.SingularQuotaIncreaseLabel, quotacontroller.AdoptedQuotaIncreaseName))
+			} else {
+				Expect(nsObj.Labels).ToNot(HaveKey(quotav1alpha1.SingularQuotaIncreaseLabel))
+			}
+
+			// the generated ResourceQuota enforces the same limit as the unmanaged ones before
+			rq, err := getResourceQuota("all")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchNumericQuantity(8)))
+			Expect(rq.Spec.Hard).ToNot(HaveKey(corev1.ResourceName("pods")))
+
+			_, err = getResourceQuota("legacy")
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+			// scoped quotas can't be converted and are left untouched
+			_, err = getResourceQuota("scoped")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(adoptionEvents()).To(ContainElements(
+				ContainSubstring("Converted ResourceQuota 'all' into QuotaIncrease 'adopted'"),
+				ContainSubstring("Converted ResourceQuota 'legacy' into QuotaIncrease 'adopted'"),
+			))
+
+			// the conversion is only done once
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(qis.Items).To(HaveLen(1))
+		},
+		Entry("cumulative mode adds the difference to the template", quotav1alpha1.CUMULATIVE, "5"),
+		Entry("maximum mode requests the limit", quotav1alpha1.MAXIMUM, "8"),
+		Entry("singular mode requests the limit and references the QuotaIncrease", quotav1alpha1.SINGULAR, "8"),
+	)
+
+	It("should not create a QuotaIncrease if the template already preserves the limits with the 'merge-as-increase' policy", func() {
+		setAdoptionPolicy(quotav1alpha1.ADOPTION_MERGE_AS_INCREASE)
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		// 'legacy' limits the service accounts to 2, which is below the template value
+		qis := &quotav1alpha1.QuotaIncreaseList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(qis.Items).To(BeEmpty())
+		rq, err := getResourceQuota("all")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchNumericQuantity(3)))
+		_, err = getResourceQuota("legacy")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+		Expect(adoptionEvents()).To(ContainElement(ContainSubstring("limits preserved by ResourceQuota 'all'")))
+	})
+
+	It("should only record the adoption in audit mode", func() {
+		qc := env.Reconciler(rec).(*quotacontroller.QuotaController)
+		qc.AuditLog = quotacontroller.NewAuditLog()
+		setAdoptionPolicy(quotav1alpha1.ADOPTION_REPLACE)
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		_, err := getResourceQuota("legacy")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(qc.AuditLog.Changes()).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+			"Kind":    Equal("ResourceQuota"),
+			"Name":    Equal("legacy"),
+			"Action":  Equal(quotacontroller.AuditActionDelete),
+			"Details": ContainSubstring("replaced by ResourceQuota 'all'"),
+		})))
+		Expect(adoptionEvents()).To(BeEmpty())
+	})
+
+})
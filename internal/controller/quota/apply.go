@@ -0,0 +1,143 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// FieldManager returns the name of the field manager which is used for server-side apply.
+// It contains the provider name, so that multiple instances of this platform service don't claim each other's fields.
+func (r *QuotaController) FieldManager() string {
+	return quotav1alpha1.LabelPrefix + "/" + r.ProviderName
+}
+
+// apply applies the given apply configuration via server-side apply, using the field manager of this controller.
+// Conflicts with other field managers, e.g. GitOps tools which manage the same fields, are logged and reported as a Warning event on the given namespace, including the conflicting fields and managers.
+// Depending on the drift policy of the namespace, the conflict is then resolved by forcing ownership, so that the desired state is restored,
+// or - with the 'reportOnly' policy - the object is left unchanged and a permanent error is returned.
+func (r *QuotaController) apply(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, obj runtime.ApplyConfiguration, kind, name string) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	err := tgt.Client().Apply(ctx, obj, client.FieldOwner(r.FieldManager()))
+	if err == nil || !apierrors.IsConflict(err) {
+		return classifyApplyError(err)
+	}
+	conflicts := applyConflicts(err)
+	policy := driftPolicy(namespace)
+	log.Info("Fields managed by this controller have been modified by other field managers", "kind", kind, "name", name, "conflicts", conflicts, "policy", policy)
+	if policy == quotav1alpha1.DriftPolicyReportOnly {
+		r.recordEvent(ctx, tgt, namespace, corev1.EventTypeWarning, "ApplyConflict", fmt.Sprintf("%s '%s' is not updated, because fields managed by this controller have been modified by other field managers (policy: %s): %s", kind, name, policy, strings.Join(conflicts, "; ")))
+		return NewPermanentError(fmt.Errorf("error applying %s '%s': %w", kind, name, err))
+	}
+	r.recordEvent(ctx, tgt, namespace, corev1.EventTypeWarning, "ApplyConflict", fmt.Sprintf("Taking over fields of %s '%s' which have been modified by other field managers (policy: %s): %s", kind, name, policy, strings.Join(conflicts, "; ")))
+	if err := tgt.Client().Apply(ctx, obj, client.FieldOwner(r.FieldManager()), client.ForceOwnership); err != nil {
+		return classifyApplyError(fmt.Errorf("error force-applying %s '%s' after conflict: %w", kind, name, err))
+	}
+	return nil
+}
+
+// classifyApplyError marks errors for objects which have been rejected as invalid by the API server as config errors.
+// All objects applied by this controller are generated from the QuotaServiceConfig, so only a config change can fix them.
+func classifyApplyError(err error) error {
+	if err != nil && apierrors.IsInvalid(err) {
+		return NewConfigError(err)
+	}
+	return err
+}
+
+// applyConflicts extracts a human-readable list of conflicting fields from a server-side apply conflict error.
+func applyConflicts(err error) []string {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil || len(status.Status().Details.Causes) == 0 {
+		return []string{err.Error()}
+	}
+	res := make([]string, 0, len(status.Status().Details.Causes))
+	for _, cause := range status.Status().Details.Causes {
+		res = append(res, strings.TrimSpace(fmt.Sprintf("%s %s", cause.Field, cause.Message)))
+	}
+	return res
+}
+
+// controllerReference returns an owner reference apply configuration which marks the given namespace as controller.
+func controllerReference(tgt *clusters.Cluster, namespace *corev1.Namespace) (*metav1ac.OwnerReferenceApplyConfiguration, error) {
+	gvk, err := apiutil.GVKForObject(namespace, tgt.Scheme())
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine GroupVersionKind for namespace: %w", err)
+	}
+	return metav1ac.OwnerReference().
+		WithAPIVersion(gvk.GroupVersion().String()).
+		WithKind(gvk.Kind).
+		WithName(namespace.Name).
+		WithUID(namespace.UID).
+		WithController(true).
+		WithBlockOwnerDeletion(true), nil
+}
+
+// resourceQuotaApplyConfiguration converts the given ResourceQuota into an apply configuration.
+// Only name, namespace, labels, annotations and spec are taken into account.
+func resourceQuotaApplyConfiguration(rq *corev1.ResourceQuota) *corev1ac.ResourceQuotaApplyConfiguration {
+	spec := corev1ac.ResourceQuotaSpec()
+	if rq.Spec.Hard != nil {
+		spec.WithHard(rq.Spec.Hard)
+	}
+	if len(rq.Spec.Scopes) > 0 {
+		spec.WithScopes(rq.Spec.Scopes...)
+	}
+	if rq.Spec.ScopeSelector != nil {
+		sel := corev1ac.ScopeSelector()
+		for _, req := range rq.Spec.ScopeSelector.MatchExpressions {
+			sel.WithMatchExpressions(corev1ac.ScopedResourceSelectorRequirement().
+				WithScopeName(req.ScopeName).
+				WithOperator(req.Operator).
+				WithValues(req.Values...))
+		}
+		spec.WithScopeSelector(sel)
+	}
+	return corev1ac.ResourceQuota(rq.Name, rq.Namespace).
+		WithLabels(rq.Labels).
+		WithAnnotations(rq.Annotations).
+		WithSpec(spec)
+}
+
+// limitRangeApplyConfiguration converts the given LimitRange into an apply configuration.
+// Only name, namespace, labels, annotations and spec are taken into account.
+func limitRangeApplyConfiguration(lr *corev1.LimitRange) *corev1ac.LimitRangeApplyConfiguration {
+	spec := corev1ac.LimitRangeSpec()
+	for _, item := range lr.Spec.Limits {
+		itemAC := corev1ac.LimitRangeItem().WithType(item.Type)
+		if item.Max != nil {
+			itemAC.WithMax(item.Max)
+		}
+		if item.Min != nil {
+			itemAC.WithMin(item.Min)
+		}
+		if item.Default != nil {
+			itemAC.WithDefault(item.Default)
+		}
+		if item.DefaultRequest != nil {
+			itemAC.WithDefaultRequest(item.DefaultRequest)
+		}
+		if item.MaxLimitRequestRatio != nil {
+			itemAC.WithMaxLimitRequestRatio(item.MaxLimitRequestRatio)
+		}
+		spec.WithLimits(itemAC)
+	}
+	return corev1ac.LimitRange(lr.Name, lr.Namespace).
+		WithLabels(lr.Labels).
+		WithAnnotations(lr.Annotations).
+		WithSpec(spec)
+}
@@ -0,0 +1,244 @@
+package quota
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+)
+
+const (
+	// ModeEnforce is the default mode of the controller, in which the computed state is written to the clusters.
+	ModeEnforce = "enforce"
+	// ModeAudit is the mode in which the controller computes everything, but does not write to the clusters.
+	// The changes it would have made are recorded in an AuditLog instead.
+	ModeAudit = "audit"
+)
+
+// AuditAction is the kind of write which the controller would have performed.
+type AuditAction string
+
+const (
+	// AuditActionCreate means that the object would have been created.
+	AuditActionCreate AuditAction = "create"
+	// AuditActionUpdate means that the object would have been modified.
+	AuditActionUpdate AuditAction = "update"
+	// AuditActionDelete means that the object would have been deleted.
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditChange is a write which the controller would have performed, if it did not run in audit mode.
+type AuditChange struct {
+	// Cluster is the ID of the cluster which contains the object.
+	Cluster string `json:"cluster"`
+	// Namespace is the namespace which is reconciled. For namespaced objects, this is also their namespace.
+	Namespace string `json:"namespace"`
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Name is the name of the object.
+	Name string `json:"name"`
+	// Action is the kind of write.
+	Action AuditAction `json:"action"`
+	// Details describes the change in a human-readable form.
+	Details string `json:"details,omitempty"`
+}
+
+// String returns a human-readable description of the change.
+func (c AuditChange) String() string {
+	res := fmt.Sprintf("%s %s '%s'", c.Action, c.Kind, c.Name)
+	if c.Details != "" {
+		res = fmt.Sprintf("%s: %s", res, c.Details)
+	}
+	return res
+}
+
+// auditNamespace identifies a reconciled namespace across clusters.
+type auditNamespace struct {
+	cluster   string
+	namespace string
+}
+
+// NewAuditLog creates a new, empty AuditLog.
+func NewAuditLog() *AuditLog {
+	return &AuditLog{
+		changes: map[auditNamespace][]AuditChange{},
+	}
+}
+
+// AuditLog contains the changes which the controller would have performed in audit mode, per namespace.
+// The changes of a namespace are replaced whenever it has been reconciled successfully, so the log contains the changes which are pending according to the latest reconcile of each namespace.
+type AuditLog struct {
+	lock    sync.RWMutex
+	changes map[auditNamespace][]AuditChange
+}
+
+// Changes returns all pending changes, sorted by cluster, namespace, kind and name.
+func (l *AuditLog) Changes() []AuditChange {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	res := []AuditChange{}
+	for _, changes := range l.changes {
+		res = append(res, changes...)
+	}
+	slices.SortFunc(res, func(a, b AuditChange) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return res
+}
+
+// update replaces the pending changes of the given namespace and returns the changes which were not pending before.
+func (l *AuditLog) update(cluster, namespace string, changes []AuditChange) []AuditChange {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := auditNamespace{cluster: cluster, namespace: namespace}
+	old := l.changes[key]
+	for _, c := range old {
+		auditPendingChanges.WithLabelValues(c.Kind, string(c.Action)).Dec()
+	}
+	added := []AuditChange{}
+	for _, c := range changes {
+		auditPendingChanges.WithLabelValues(c.Kind, string(c.Action)).Inc()
+		if !slices.Contains(old, c) {
+			added = append(added, c)
+		}
+	}
+	if len(changes) == 0 {
+		delete(l.changes, key)
+	} else {
+		l.changes[key] = changes
+	}
+	return added
+}
+
+// auditCollectorKey is the context key for the auditCollector of a reconcile.
+type auditCollectorKey struct{}
+
+// auditCollector collects the changes of a single namespace reconcile in audit mode.
+type auditCollector struct {
+	changes []AuditChange
+}
+
+// auditing returns true if the controller runs in audit mode.
+func (r *QuotaController) auditing() bool {
+	return r.AuditLog != nil
+}
+
+// write performs the given write, unless the controller runs in audit mode.
+// In audit mode, the given change is recorded instead and reported once the reconcile of the namespace has finished, see auditNamespaceChanges.
+// The change is nil if the write would not modify anything, e.g. applying an object which is already up-to-date.
+func (r *QuotaController) write(ctx context.Context, change *AuditChange, fn func() error) error {
+	if !r.auditing() {
+		return fn()
+	}
+	if c, ok := ctx.Value(auditCollectorKey{}).(*auditCollector); ok && change != nil {
+		c.changes = append(c.changes, *change)
+	}
+	return nil
+}
+
+// auditNamespaceChanges runs the given reconcile of a namespace and, in audit mode, records the changes it would have performed in the AuditLog.
+// Changes which were not pending before are logged and reported as events on the namespace, so each change is only reported once.
+// The pending changes of the namespace are only replaced if the reconcile succeeded.
+func (r *QuotaController) auditNamespaceChanges(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, reconcileFn func(ctx context.Context) error) error {
+	if !r.auditing() {
+		return reconcileFn(ctx)
+	}
+	c := &auditCollector{}
+	if err := reconcileFn(context.WithValue(ctx, auditCollectorKey{}, c)); err != nil {
+		return err
+	}
+	for i := range c.changes {
+		c.changes[i].Cluster = tgt.ID()
+		c.changes[i].Namespace = ns.Name
+	}
+	log := logging.FromContextOrPanic(ctx)
+	for _, change := range r.AuditLog.update(tgt.ID(), ns.Name, c.changes) {
+		log.Info("Audit mode: skipped change", "kind", change.Kind, "name", change.Name, "action", change.Action, "details", change.Details)
+		r.recordEvent(ctx, tgt, ns, corev1.EventTypeNormal, "AuditChange", fmt.Sprintf("Change skipped in audit mode: %s", change.String()))
+	}
+	return nil
+}
+
+// forgetAuditChanges removes the pending changes of a namespace which is not reconciled anymore, e.g. because it has been deleted or no quota definition matches it anymore.
+func (r *QuotaController) forgetAuditChanges(tgt *clusters.Cluster, namespace string) {
+	if r.auditing() {
+		r.AuditLog.update(tgt.ID(), namespace, nil)
+	}
+}
+
+// resourceQuotaChange describes how applying the desired ResourceQuota would modify the live one, which is nil if it does not exist.
+// Returns nil if the live ResourceQuota is up-to-date.
+func resourceQuotaChange(desired, live *corev1.ResourceQuota) *AuditChange {
+	change := &AuditChange{Kind: "ResourceQuota", Name: desired.Name, Action: AuditActionUpdate}
+	if live == nil {
+		change.Action = AuditActionCreate
+		change.Details = fmt.Sprintf("hard: %s", effectAsString(desired.Spec.Hard))
+		return change
+	}
+	details := []string{}
+	for _, c := range hardLimitChanges(live.Spec.Hard, desired.Spec.Hard) {
+		details = append(details, c.String())
+	}
+	if !equality.Semantic.DeepEqual(desired.Spec.Scopes, live.Spec.Scopes) || !equality.Semantic.DeepEqual(desired.Spec.ScopeSelector, live.Spec.ScopeSelector) {
+		details = append(details, "scopes")
+	}
+	details = append(details, labelChanges(desired.Labels, live.Labels)...)
+	details = append(details, annotationChanges(desired.Annotations, live.Annotations)...)
+	if len(details) == 0 {
+		return nil
+	}
+	change.Details = strings.Join(details, ", ")
+	return change
+}
+
+// limitRangeChange describes how applying the desired LimitRange would modify the live one, which is nil if it does not exist.
+// Returns nil if the live LimitRange is up-to-date.
+func limitRangeChange(desired, live *corev1.LimitRange) *AuditChange {
+	change := &AuditChange{Kind: "LimitRange", Name: desired.Name, Action: AuditActionUpdate}
+	if live == nil {
+		change.Action = AuditActionCreate
+		return change
+	}
+	details := []string{}
+	if !equality.Semantic.DeepEqual(desired.Spec, live.Spec) {
+		details = append(details, "limits")
+	}
+	details = append(details, labelChanges(desired.Labels, live.Labels)...)
+	if len(details) == 0 {
+		return nil
+	}
+	change.Details = strings.Join(details, ", ")
+	return change
+}
+
+// labelChanges returns a description of each label from desired which is missing in or differs from live, sorted by key.
+func labelChanges(desired, live map[string]string) []string {
+	res := []string{}
+	for _, k := range sets.List(sets.KeySet(desired)) {
+		if v, ok := live[k]; !ok || v != desired[k] {
+			res = append(res, fmt.Sprintf("label %s=%s", k, desired[k]))
+		}
+	}
+	return res
+}
+
+// annotationChanges returns a description of each annotation from desired which is missing in or differs from live, sorted by key.
+// The values are omitted, because they can be too long to be reported, e.g. the provenance annotation.
+func annotationChanges(desired, live map[string]string) []string {
+	res := []string{}
+	for _, k := range sets.List(sets.KeySet(desired)) {
+		if v, ok := live[k]; !ok || v != desired[k] {
+			res = append(res, fmt.Sprintf("annotation %s", k))
+		}
+	}
+	return res
+}
@@ -0,0 +1,122 @@
+package quota_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Audit Mode", func() {
+
+	var env *testutils.ComplexEnvironment
+	var qc *quotacontroller.QuotaController
+
+	// auditEvents returns the messages of all events which report skipped changes.
+	auditEvents := func() []string {
+		evs := &corev1.EventList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, evs)).To(Succeed())
+		res := []string{}
+		for _, ev := range evs.Items {
+			if ev.Reason == "AuditChange" {
+				res = append(res, ev.Message)
+			}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, true, "testdata", "test-01")
+		qc = env.Reconciler(rec).(*quotacontroller.QuotaController)
+		qc.AuditLog = quotacontroller.NewAuditLog()
+	})
+
+	It("should not write anything, but record the changes which would have been performed", func() {
+		ns := &corev1.Namespace{}
+		ns.SetName("ns-project")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		// nothing has been written
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+		Expect(ns.Labels).ToNot(HaveKey(quotav1alpha1.ManagedByLabel))
+		rql := &corev1.ResourceQuotaList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, rql, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(rql.Items).To(BeEmpty())
+		qis := &quotav1alpha1.QuotaIncreaseList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(qis.Items).To(HaveLen(4))
+		for _, qi := range qis.Items {
+			Expect(qi.Annotations).ToNot(HaveKey(quotav1alpha1.EffectAnnotation))
+		}
+
+		// but the changes have been recorded
+		changes := qc.AuditLog.Changes()
+		Expect(changes).To(ContainElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Namespace": Equal("ns-project"),
+				"Kind":      Equal("Namespace"),
+				"Name":      Equal("ns-project"),
+				"Action":    Equal(quotacontroller.AuditActionUpdate),
+				"Details":   ContainSubstring(quotav1alpha1.ManagedByLabel + "=" + providerName),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Kind":   Equal("ResourceQuota"),
+				"Name":   Equal("project"),
+				"Action": Equal(quotacontroller.AuditActionCreate),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Kind":    Equal("QuotaIncrease"),
+				"Name":    Equal("qi-project-empty"),
+				"Action":  Equal(quotacontroller.AuditActionDelete),
+				"Details": ContainSubstring("ineffective"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Kind":   Equal("QuotaIncrease"),
+				"Name":   Equal("qi-project-max"),
+				"Action": Equal(quotacontroller.AuditActionUpdate),
+			}),
+		))
+		Expect(auditEvents()).To(HaveLen(len(changes)))
+
+		// the same changes are only reported once
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(qc.AuditLog.Changes()).To(Equal(changes))
+		Expect(auditEvents()).To(HaveLen(len(changes)))
+	})
+
+	It("should only record changes of objects which are not up-to-date", func() {
+		ns := &corev1.Namespace{}
+		ns.SetName("ns-project")
+
+		// reconcile in enforce mode first
+		qc.AuditLog = nil
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		qc.AuditLog = quotacontroller.NewAuditLog()
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(qc.AuditLog.Changes()).To(BeEmpty())
+
+		// a config change results in an update of the ResourceQuota only
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("project").ResourceQuotaTemplate.Spec.Hard["count/secrets"] = resource.MustParse("50")
+		})
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(qc.AuditLog.Changes()).To(ConsistOf(
+			MatchFields(IgnoreExtras, Fields{
+				"Kind":    Equal("ResourceQuota"),
+				"Action":  Equal(quotacontroller.AuditActionUpdate),
+				"Details": ContainSubstring("count/secrets"),
+			}),
+		))
+		rq := &corev1.ResourceQuota{}
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: "project"}, rq)).To(Succeed())
+		Expect(rq.Spec.Hard).ToNot(HaveKeyWithValue(corev1.ResourceName("count/secrets"), resource.MustParse("50")))
+	})
+
+})
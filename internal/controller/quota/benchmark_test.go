@@ -0,0 +1,314 @@
+package quota_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotainstall "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const (
+	benchmarkNamespaces       = 10000
+	benchmarkQuotaIncreases   = 50000
+	benchmarkQuotaDefinitions = 10
+	benchmarkTierLabel        = "benchmark.quota.openmcp.cloud/tier"
+)
+
+// BenchmarkReconcile measures the reconcile throughput of the QuotaController for 10k namespaces with 50k QuotaIncreases.
+// The 'cache' variant reads namespaces, QuotaIncreases, ResourceQuotas and LimitRanges from an indexed cache, like the manager does,
+// the 'client' variant reads them through the client, which has to filter all QuotaIncreases for every reconcile.
+func BenchmarkReconcile(b *testing.B) {
+	for _, cached := range []bool{true, false} {
+		name := "client"
+		if cached {
+			name = "cache"
+		}
+		b.Run(name, func(b *testing.B) {
+			ctx, qc := newBenchmarkController(b, cached)
+			i := 0
+			for b.Loop() {
+				req := reconcile.Request{NamespacedName: types.NamespacedName{Name: benchmarkNamespaceName(i % benchmarkNamespaces)}}
+				if _, err := qc.Reconcile(ctx, req); err != nil {
+					b.Fatalf("error reconciling namespace '%s': %v", req.Name, err)
+				}
+				i++
+			}
+			b.ReportMetric(float64(i)/b.Elapsed().Seconds(), "reconciles/s")
+		})
+	}
+}
+
+// BenchmarkConfigChangeHandler measures how long it takes to determine the namespaces affected by a config change for 10k namespaces.
+// The configs alternate between two generations which differ in a single quota definition.
+func BenchmarkConfigChangeHandler(b *testing.B) {
+	ctx, qc := newBenchmarkController(b, true)
+	cfgs := []*quotav1alpha1.QuotaServiceConfig{benchmarkConfig(), benchmarkConfig()}
+	cfgs[1].Generation = 2
+	cfgs[1].Spec.Quotas[0].ResourceQuotaTemplate.Spec.Hard[corev1.ResourceCPU] = resource.MustParse("20")
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+	h := qc.ConfigChangeHandler()
+	i := 0
+	for b.Loop() {
+		h.Generic(ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: cfgs[i%len(cfgs)]}, q)
+		i++
+	}
+	b.ReportMetric(float64(i*benchmarkNamespaces)/b.Elapsed().Seconds(), "namespaces/s")
+}
+
+// newBenchmarkController returns a QuotaController with a loaded config, whose onboarding cluster contains the benchmark namespaces and QuotaIncreases.
+// Namespaces and QuotaIncreases already carry the labels and annotations set by the controller, so that the benchmark measures reconciles without changes.
+// If cached is true, the onboarding cluster is read from an indexedReader, which is kept up-to-date with the writes of the controller.
+func newBenchmarkController(b *testing.B, cached bool) (context.Context, *quotacontroller.QuotaController) {
+	b.Helper()
+	ctx := logging.NewContextWithDiscard(context.Background())
+
+	cfg := benchmarkConfig()
+	objs := make([]client.Object, 0, benchmarkNamespaces+benchmarkQuotaIncreases)
+	for i := range benchmarkNamespaces {
+		ns := &corev1.Namespace{}
+		ns.SetName(benchmarkNamespaceName(i))
+		qd := cfg.Spec.Quotas[i%benchmarkQuotaDefinitions]
+		ns.SetLabels(map[string]string{
+			benchmarkTierLabel:                            strconv.Itoa(i % benchmarkQuotaDefinitions),
+			quotav1alpha1.ManagedByLabel:                  providerName,
+			quotav1alpha1.BaseQuotaLabel:                  qd.Name,
+			quotav1alpha1.QuotaIncreaseOperationModeLabel: string(qd.Mode),
+		})
+		objs = append(objs, ns)
+	}
+	for i := range benchmarkQuotaIncreases {
+		qi := &quotav1alpha1.QuotaIncrease{}
+		qi.SetName(fmt.Sprintf("qi-%d", i))
+		qi.SetNamespace(benchmarkNamespaceName(i % benchmarkNamespaces))
+		qi.SetLabels(map[string]string{quotav1alpha1.QuotaIncreaseOperationModeLabel: string(quotav1alpha1.CUMULATIVE)})
+		qi.SetAnnotations(map[string]string{quotav1alpha1.EffectAnnotation: "cpu: 1"})
+		qi.Spec.Hard = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+		objs = append(objs, qi)
+	}
+
+	reader := newIndexedReader()
+	for _, obj := range objs {
+		if err := reader.update(obj); err != nil {
+			b.Fatal(err)
+		}
+	}
+	onboarding := interceptor.NewClient(fake.NewClientBuilder().
+		WithScheme(quotainstall.InstallOperatorAPIsOnboarding(runtime.NewScheme())).
+		WithObjects(objs...).
+		Build(), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+				return err
+			}
+			return reader.update(obj)
+		},
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			if err := c.Apply(ctx, obj, opts...); err != nil {
+				return err
+			}
+			applied := appliedObject(obj)
+			if applied == nil {
+				return nil
+			}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(applied), applied); err != nil {
+				return err
+			}
+			return reader.update(applied)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if err := c.Delete(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return reader.delete(obj)
+		},
+	})
+	platform := fake.NewClientBuilder().
+		WithScheme(quotainstall.InstallOperatorAPIsPlatform(runtime.NewScheme())).
+		WithObjects(cfg).
+		WithStatusSubresource(&quotav1alpha1.QuotaServiceConfig{}).
+		Build()
+
+	qc := quotacontroller.NewQuotaController(clusters.NewTestClusterFromClient(platformCluster, platform), clusters.NewTestClusterFromClient(onboardingCluster, onboarding), providerName)
+	if cached {
+		qc.OnboardingCache = reader
+	}
+	if _, err := qc.Configs.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: providerName}}); err != nil {
+		b.Fatalf("error loading config: %v", err)
+	}
+	if qc.Configs.Active() == nil {
+		b.Fatal("config has not been loaded")
+	}
+	return ctx, qc
+}
+
+// benchmarkConfig returns a config with one quota definition per tier, which selects the namespaces by their tier label.
+func benchmarkConfig() *quotav1alpha1.QuotaServiceConfig {
+	cfg := &quotav1alpha1.QuotaServiceConfig{}
+	cfg.SetName(providerName)
+	cfg.Generation = 1
+	for i := range benchmarkQuotaDefinitions {
+		cfg.Spec.Quotas = append(cfg.Spec.Quotas, &quotav1alpha1.QuotaDefinition{
+			Name: fmt.Sprintf("tier-%d", i),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{benchmarkTierLabel: strconv.Itoa(i)},
+			},
+			ResourceQuotaTemplate: &quotav1alpha1.ResourceQuotaTemplate{
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10"),
+						corev1.ResourceMemory: resource.MustParse("10Gi"),
+					},
+				},
+			},
+			Mode: quotav1alpha1.CUMULATIVE,
+		})
+	}
+	return cfg
+}
+
+func benchmarkNamespaceName(i int) string {
+	return fmt.Sprintf("ns-%05d", i)
+}
+
+// appliedObject returns an empty object with the name and namespace of the given apply configuration, if its type is cached by the indexedReader.
+func appliedObject(ac runtime.ApplyConfiguration) client.Object {
+	var obj client.Object
+	var name, namespace *string
+	switch ac := ac.(type) {
+	case *corev1ac.NamespaceApplyConfiguration:
+		obj, name = &corev1.Namespace{}, ac.GetName()
+	case *corev1ac.ResourceQuotaApplyConfiguration:
+		obj, name, namespace = &corev1.ResourceQuota{}, ac.GetName(), ac.GetNamespace()
+	case *corev1ac.LimitRangeApplyConfiguration:
+		obj, name, namespace = &corev1.LimitRange{}, ac.GetName(), ac.GetNamespace()
+	default:
+		return nil
+	}
+	obj.SetName(ptr.Deref(name, ""))
+	obj.SetNamespace(ptr.Deref(namespace, ""))
+	return obj
+}
+
+// indexedReader is a client.Reader for namespaces, QuotaIncreases, ResourceQuotas and LimitRanges.
+// Like the informer cache of the manager, it serves lists within a namespace from a namespace index instead of filtering all objects.
+type indexedReader struct {
+	namespaces     toolscache.Indexer
+	quotaIncreases toolscache.Indexer
+	resourceQuotas toolscache.Indexer
+	limitRanges    toolscache.Indexer
+}
+
+var _ client.Reader = &indexedReader{}
+
+func newIndexedReader() *indexedReader {
+	indexers := toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc}
+	return &indexedReader{
+		namespaces:     toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, indexers),
+		quotaIncreases: toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, indexers),
+		resourceQuotas: toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, indexers),
+		limitRanges:    toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, indexers),
+	}
+}
+
+// indexer returns the indexer for the type of the given object or list.
+// Returns nil for types which are not cached.
+func (r *indexedReader) indexer(obj runtime.Object) toolscache.Indexer {
+	switch obj.(type) {
+	case *corev1.Namespace, *corev1.NamespaceList:
+		return r.namespaces
+	case *quotav1alpha1.QuotaIncrease, *quotav1alpha1.QuotaIncreaseList:
+		return r.quotaIncreases
+	case *corev1.ResourceQuota, *corev1.ResourceQuotaList:
+		return r.resourceQuotas
+	case *corev1.LimitRange, *corev1.LimitRangeList:
+		return r.limitRanges
+	}
+	return nil
+}
+
+// update stores a copy of the given object, if its type is cached.
+func (r *indexedReader) update(obj client.Object) error {
+	if idx := r.indexer(obj); idx != nil {
+		return idx.Update(obj.DeepCopyObject())
+	}
+	return nil
+}
+
+// delete removes the given object, if its type is cached.
+func (r *indexedReader) delete(obj client.Object) error {
+	if idx := r.indexer(obj); idx != nil {
+		return idx.Delete(obj)
+	}
+	return nil
+}
+
+func (r *indexedReader) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	idx := r.indexer(obj)
+	if idx == nil {
+		return fmt.Errorf("type %T is not cached", obj)
+	}
+	k := key.Name
+	if key.Namespace != "" {
+		k = key.Namespace + "/" + key.Name
+	}
+	item, exists, err := idx.GetByKey(k)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(item.(runtime.Object).DeepCopyObject()).Elem())
+	return nil
+}
+
+func (r *indexedReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	idx := r.indexer(list)
+	if idx == nil {
+		return fmt.Errorf("type %T is not cached", list)
+	}
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if listOpts.LabelSelector != nil || listOpts.FieldSelector != nil {
+		return fmt.Errorf("selectors are not supported")
+	}
+	items := idx.List()
+	if listOpts.Namespace != "" {
+		var err error
+		if items, err = idx.ByIndex(toolscache.NamespaceIndex, listOpts.Namespace); err != nil {
+			return err
+		}
+	}
+	objs := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		objs = append(objs, item.(runtime.Object).DeepCopyObject())
+	}
+	return meta.SetList(list, objs)
+}
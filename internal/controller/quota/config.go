@@ -0,0 +1,536 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+const ConfigControllerName = "quota-config"
+
+// NewConfigWatcher creates a new ConfigWatcher for the QuotaServiceConfig with the given name.
+func NewConfigWatcher(platformCluster *clusters.Cluster, providerName string) *ConfigWatcher {
+	return &ConfigWatcher{
+		PlatformCluster: platformCluster,
+		ProviderName:    providerName,
+		statusTrigger:   make(chan event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig], 1),
+	}
+}
+
+// ConfigWatcher reconciles the QuotaServiceConfig on the platform cluster and provides the active config to the quota controllers.
+// Each generation of the config is validated only once. If a generation is invalid, it is rejected and the last valid generation stays active.
+// The result is reported in the status of the QuotaServiceConfig.
+// Subscribers are notified whenever a new generation becomes active.
+// If the new generation has a rollout policy, the previously active generation stays in use for all namespaces which have not been updated by the staged rollout yet.
+// Reconcile errors caused by the config are recorded by the quota controllers and reported in the 'Applied' condition of the config.
+// Uncorrected drift of the generated ResourceQuotas is recorded as well and reported in the 'InSync' condition.
+type ConfigWatcher struct {
+	PlatformCluster *clusters.Cluster
+	ProviderName    string
+	// IgnoreRolloutPolicy makes new generations apply to all namespaces at once, even if they have a rollout policy.
+	// This is used in audit mode, because the staged rollout requires writing to the namespaces.
+	IgnoreRolloutPolicy bool
+	// WaitForLeadership makes the watcher write the status of the config only after StartStatusUpdates has been called, i.e. once this replica has become the leader.
+	// The config itself is loaded independent of it, because all replicas need it. Enabled by SetupWithManager.
+	WaitForLeadership bool
+
+	lock sync.RWMutex
+	// active is the last valid config. It is replaced, but never modified, so it can be shared without copying.
+	active *quotav1alpha1.QuotaServiceConfig
+	// stable is the config which is used for namespaces that have not been updated yet while a staged rollout of the active config is in progress.
+	// It is nil if no rollout is in progress. Like active, it is never modified.
+	stable *quotav1alpha1.QuotaServiceConfig
+	// selectors contains the compiled selectors of the active and the stable config. It is replaced together with them.
+	selectors *selectorCache
+	// observed identifies the latest config which has been validated, independent of whether it was valid or not.
+	// It is nil if no config has been observed yet or the config has been deleted.
+	observed *configVersion
+	// err is the reason why the latest config is not active, if any.
+	err         error
+	subscribers []chan event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]
+	// configErrors contains the messages of the config errors of all objects whose latest reconcile failed because of the config, by object.
+	configErrors map[string]string
+	// drifts contains the uncorrected drift of the generated ResourceQuotas, by cluster and namespace.
+	drifts map[driftKey]string
+	// leading is true once StartStatusUpdates has been called, see WaitForLeadership.
+	leading bool
+	// tracksResults is true once a reconcile result has been recorded. Only then the 'Applied' and 'InSync' conditions are maintained,
+	// so that they are not reported before anything has been reconciled with the active config.
+	tracksResults bool
+	// statusTrigger triggers a reconcile of the config, which updates its status, after the recorded config errors changed.
+	statusTrigger chan event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]
+}
+
+// maxReportedConfigErrors is the maximum number of objects whose config errors are listed in the 'Applied' condition, and of namespaces whose drift is listed in the 'InSync' condition.
+const maxReportedConfigErrors = 5
+
+// configVersion identifies a generation of a config.
+// The UID is required to detect re-created configs, whose generation starts at 1 again.
+type configVersion struct {
+	uid        types.UID
+	generation int64
+}
+
+// Active returns the currently active config or nil, if no valid config has been loaded yet.
+// The returned config is shared and must not be modified.
+func (w *ConfigWatcher) Active() *quotav1alpha1.QuotaServiceConfig {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.active
+}
+
+// ForNamespace returns the config which applies to the given namespace or nil, if no valid config has been loaded yet.
+// This is the active config, unless a staged rollout is in progress and the namespace has not been updated to the active generation yet.
+// The returned config is shared and must not be modified.
+func (w *ConfigWatcher) ForNamespace(ns *corev1.Namespace) *quotav1alpha1.QuotaServiceConfig {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.stable == nil || ns.Labels[quotav1alpha1.ConfigGenerationLabel] == strconv.FormatInt(w.active.Generation, 10) {
+		return w.active
+	}
+	return w.stable
+}
+
+// compiledSelectors returns the compiled selectors of the active and the stable config.
+func (w *ConfigWatcher) compiledSelectors() *selectorCache {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.selectors
+}
+
+// Rollout returns the stable and the active config of the staged rollout which is currently in progress.
+// The stable config is nil if no rollout is in progress. The returned configs are shared and must not be modified.
+func (w *ConfigWatcher) Rollout() (stable, active *quotav1alpha1.QuotaServiceConfig) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.stable, w.active
+}
+
+// CompleteRollout marks the staged rollout of the given generation as completed, so that the active config is used for all namespaces.
+// Returns false if the given generation is not active anymore.
+func (w *ConfigWatcher) CompleteRollout(generation int64) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.active == nil || w.active.Generation != generation {
+		return false
+	}
+	w.stable = nil
+	return true
+}
+
+// ActiveConfig returns a copy of the currently active config or nil, if no valid config has been loaded yet.
+func (w *ConfigWatcher) ActiveConfig() *quotav1alpha1.QuotaServiceConfig {
+	return w.Active().DeepCopy()
+}
+
+// Check is a readiness check which fails if no valid config has been loaded yet or if the latest config is not active,
+// because it has been deleted or is invalid.
+func (w *ConfigWatcher) Check(_ *http.Request) error {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.active == nil {
+		return fmt.Errorf("QuotaServiceConfig '%s' has not been loaded yet", w.ProviderName)
+	}
+	return nil
+}
+
+// RecordResult records the result of a reconcile of the given object, e.g. "namespace 'foo'".
+// Config errors are reported in the 'Applied' condition of the config until the object has been reconciled successfully.
+func (w *ConfigWatcher) RecordResult(object string, err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	changed := !w.tracksResults
+	w.tracksResults = true
+	old, hadConfigError := w.configErrors[object]
+	switch {
+	case err != nil && ErrorClassOf(err) == ErrorClassConfig:
+		if w.configErrors == nil {
+			w.configErrors = map[string]string{}
+		}
+		w.configErrors[object] = err.Error()
+		changed = changed || !hadConfigError || old != err.Error()
+	case err == nil && hadConfigError:
+		delete(w.configErrors, object)
+		changed = true
+	}
+	if changed {
+		w.triggerStatusUpdate()
+	}
+}
+
+// driftKey identifies a namespace in a cluster for the recorded drift.
+type driftKey struct {
+	cluster   string
+	namespace string
+}
+
+// String returns a human-readable description of the namespace, which is used in the 'InSync' condition.
+func (k driftKey) String() string {
+	return fmt.Sprintf("namespace '%s' in cluster '%s'", k.namespace, k.cluster)
+}
+
+// RecordDrift records the uncorrected drift of the generated ResourceQuota in the given namespace of the cluster with the given ID.
+// An empty drift means that the ResourceQuota matches its desired state or the namespace is not reconciled anymore.
+// The drift is reported in the 'InSync' condition of the config.
+func (w *ConfigWatcher) RecordDrift(cluster, namespace, drift string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	key := driftKey{cluster: cluster, namespace: namespace}
+	old, hadDrift := w.drifts[key]
+	changed := false
+	switch {
+	case drift != "":
+		if w.drifts == nil {
+			w.drifts = map[driftKey]string{}
+		}
+		w.drifts[key] = drift
+		changed = !hadDrift || old != drift
+	case hadDrift:
+		delete(w.drifts, key)
+		changed = true
+	}
+	if changed {
+		w.triggerStatusUpdate()
+	}
+}
+
+// ForgetClusterDrift removes the recorded drift of all namespaces in the cluster with the given ID which are not contained in keep.
+// This is used for namespaces and clusters which are not reconciled anymore, e.g. because they have been deleted. A nil keep removes the drift of all namespaces.
+func (w *ConfigWatcher) ForgetClusterDrift(cluster string, keep sets.Set[string]) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	changed := false
+	for key := range w.drifts {
+		if key.cluster == cluster && !keep.Has(key.namespace) {
+			delete(w.drifts, key)
+			changed = true
+		}
+	}
+	if changed {
+		w.triggerStatusUpdate()
+	}
+}
+
+// triggerStatusUpdate triggers a reconcile of the config, which updates its status, if results are tracked.
+// Must be called with the lock held.
+func (w *ConfigWatcher) triggerStatusUpdate() {
+	if !w.tracksResults {
+		return
+	}
+	// a pending trigger is sufficient, since the status is computed when the config is reconciled
+	select {
+	case w.statusTrigger <- event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: w.active}:
+	default:
+	}
+}
+
+// inSyncCondition returns the 'InSync' condition for the active config, based on the recorded drift.
+// Must be called with the lock held.
+func (w *ConfigWatcher) inSyncCondition() metav1.Condition {
+	cond := metav1.Condition{
+		Type:               quotav1alpha1.ConfigConditionInSync,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: w.active.Generation,
+		Reason:             quotav1alpha1.ConfigReasonInSync,
+		Message:            "All generated ResourceQuotas match their desired state.",
+	}
+	if len(w.drifts) == 0 {
+		return cond
+	}
+	namespaces := slices.SortedFunc(maps.Keys(w.drifts), func(a, b driftKey) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	details := make([]string, 0, maxReportedConfigErrors)
+	for _, ns := range namespaces[:min(len(namespaces), maxReportedConfigErrors)] {
+		details = append(details, fmt.Sprintf("%s: %s", ns, w.drifts[ns]))
+	}
+	if len(namespaces) > maxReportedConfigErrors {
+		details = append(details, fmt.Sprintf("and %d more", len(namespaces)-maxReportedConfigErrors))
+	}
+	cond.Status = metav1.ConditionFalse
+	cond.Reason = quotav1alpha1.ConfigReasonDriftNotCorrected
+	cond.Message = fmt.Sprintf("The drift of the ResourceQuotas in %d namespaces is not corrected due to their drift policy: %s", len(namespaces), strings.Join(details, "; "))
+	return cond
+}
+
+// appliedCondition returns the 'Applied' condition for the active config, based on the recorded config errors.
+// Must be called with the lock held.
+func (w *ConfigWatcher) appliedCondition() metav1.Condition {
+	cond := metav1.Condition{
+		Type:               quotav1alpha1.ConfigConditionApplied,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: w.active.Generation,
+		Reason:             quotav1alpha1.ConfigReasonApplied,
+		Message:            fmt.Sprintf("No reconcile failed because of generation %d.", w.active.Generation),
+	}
+	if len(w.configErrors) == 0 {
+		return cond
+	}
+	objects := slices.Sorted(maps.Keys(w.configErrors))
+	details := make([]string, 0, maxReportedConfigErrors)
+	for _, obj := range objects[:min(len(objects), maxReportedConfigErrors)] {
+		details = append(details, fmt.Sprintf("%s: %s", obj, w.configErrors[obj]))
+	}
+	if len(objects) > maxReportedConfigErrors {
+		details = append(details, fmt.Sprintf("and %d more", len(objects)-maxReportedConfigErrors))
+	}
+	cond.Status = metav1.ConditionFalse
+	cond.Reason = quotav1alpha1.ConfigReasonConfigError
+	cond.Message = fmt.Sprintf("%d objects cannot be reconciled because of generation %d: %s", len(objects), w.active.Generation, strings.Join(details, "; "))
+	return cond
+}
+
+// Subscribe returns a channel on which the watcher sends an event whenever a new config becomes active.
+// Only the latest config is buffered, so a slow subscriber skips outdated configs, but never misses the latest one.
+// Must be called before the manager is started.
+func (w *ConfigWatcher) Subscribe() <-chan event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig] {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	ch := make(chan event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig], 1)
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// Reconcile validates a new generation of the QuotaServiceConfig and activates it, if it is valid.
+// The result is written into the status of the config, unless the watcher waits for leadership.
+func (w *ConfigWatcher) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContextOrPanic(ctx).WithName(ConfigControllerName)
+	ctx = logging.NewContext(ctx, log)
+	log.Debug("Reconcile triggered")
+
+	cfg := &quotav1alpha1.QuotaServiceConfig{}
+	if err := w.PlatformCluster.Client().Get(ctx, types.NamespacedName{Name: w.ProviderName}, cfg); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("unable to fetch QuotaServiceConfig '%s': %w", w.ProviderName, err)
+		}
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		w.observed = nil
+		w.err = fmt.Errorf("QuotaServiceConfig '%s' not found%s", w.ProviderName, w.keepingActiveSuffix())
+		log.Error(w.err, "QuotaServiceConfig has been deleted")
+		return reconcile.Result{}, nil
+	}
+
+	version := configVersion{uid: cfg.UID, generation: cfg.Generation}
+	w.lock.RLock()
+	known := w.observed != nil && *w.observed == version
+	w.lock.RUnlock()
+	if !known {
+		w.observe(ctx, cfg, version)
+	}
+
+	return reconcile.Result{}, w.updateStatus(ctx, cfg)
+}
+
+// observe validates the given config and activates it, if it is valid.
+func (w *ConfigWatcher) observe(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig, version configVersion) {
+	log := logging.FromContextOrPanic(ctx)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.observed = &version
+	if err := cfg.Spec.Validate(); err != nil {
+		w.err = fmt.Errorf("generation %d of QuotaServiceConfig '%s' is invalid%s: %w", cfg.Generation, w.ProviderName, w.keepingActiveSuffix(), err)
+		log.Error(w.err, "Rejected QuotaServiceConfig")
+		return
+	}
+	oldGeneration := int64(-1)
+	if w.active != nil {
+		oldGeneration = w.active.Generation
+	}
+	log.Info("Detected change in QuotaServiceConfig, updating internal config", "oldGeneration", oldGeneration, "newGeneration", cfg.Generation)
+	w.stable = w.rolloutStable(cfg)
+	if w.stable != nil {
+		log.Info("Starting staged rollout of QuotaServiceConfig", "stableGeneration", w.stable.Generation, "newGeneration", cfg.Generation)
+	}
+	w.active = cfg.DeepCopy()
+	w.selectors = newSelectorCache(w.active, w.stable)
+	w.err = nil
+	for _, ch := range w.subscribers {
+		// replace a pending outdated config, so that sending never blocks
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: w.active}
+	}
+}
+
+// rolloutStable returns the stable config for a staged rollout of the given config, or nil if the config is applied to all namespaces at once.
+// If a rollout is already in progress, its stable config is kept, so that namespaces which have been updated to an intermediate generation return to the stable one.
+// After a restart, the stable config is restored from the rollout status of the config.
+// Must be called with the lock held, before the given config becomes active.
+func (w *ConfigWatcher) rolloutStable(cfg *quotav1alpha1.QuotaServiceConfig) *quotav1alpha1.QuotaServiceConfig {
+	if cfg.Spec.Rollout == nil || w.IgnoreRolloutPolicy {
+		return nil
+	}
+	if w.active != nil && w.active.UID == cfg.UID {
+		if w.stable != nil {
+			return w.stable
+		}
+		return w.active
+	}
+	if w.active != nil {
+		// the config has been re-created
+		return nil
+	}
+	rs := cfg.Status.Rollout
+	if rs == nil || rs.Generation != cfg.Generation || rs.Phase == quotav1alpha1.ROLLOUT_COMPLETED || rs.StableSpec == nil {
+		return nil
+	}
+	stable := &quotav1alpha1.QuotaServiceConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       cfg.Name,
+			UID:        cfg.UID,
+			Generation: rs.StableGeneration,
+		},
+		Spec: *rs.StableSpec.DeepCopy(),
+	}
+	return stable
+}
+
+// rolloutStatus returns the rollout status for the active config, based on the current one.
+// The progress of a rollout is reported by the StagedRolloutController, this only initializes the status when a rollout starts
+// and removes the status of previous generations.
+// Must be called with the lock held.
+func (w *ConfigWatcher) rolloutStatus(current *quotav1alpha1.RolloutStatus) *quotav1alpha1.RolloutStatus {
+	if current != nil && current.Generation == w.active.Generation {
+		return current
+	}
+	if w.stable == nil {
+		return nil
+	}
+	return newRolloutStatus(w.stable, w.active)
+}
+
+// newRolloutStatus returns the initial status for a staged rollout from the stable to the target config.
+func newRolloutStatus(stable, target *quotav1alpha1.QuotaServiceConfig) *quotav1alpha1.RolloutStatus {
+	return &quotav1alpha1.RolloutStatus{
+		Generation:       target.Generation,
+		StableGeneration: stable.Generation,
+		StableSpec:       stable.Spec.DeepCopy(),
+		Phase:            quotav1alpha1.ROLLOUT_PROGRESSING,
+		Message:          fmt.Sprintf("Rolling out generation %d, namespaces which have not been updated yet use generation %d.", target.Generation, stable.Generation),
+	}
+}
+
+// keepingActiveSuffix returns a message suffix which names the generation that stays active.
+// Must be called with the lock held.
+func (w *ConfigWatcher) keepingActiveSuffix() string {
+	if w.active == nil {
+		return ""
+	}
+	return fmt.Sprintf(", keeping generation %d active", w.active.Generation)
+}
+
+// StartStatusUpdates allows the watcher to write the status of the config, if WaitForLeadership is set, and triggers a reconcile of the config to write it.
+// It is added to the manager as runnable which requires leader election by SetupWithManager, so that only the leader writes the status and the conditions don't flap between the replicas.
+func (w *ConfigWatcher) StartStatusUpdates(_ context.Context) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.leading = true
+	// unlike triggerStatusUpdate, this is required even if no results are tracked yet, e.g. to report the 'Valid' condition
+	select {
+	case w.statusTrigger <- event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: w.active}:
+	default:
+	}
+	return nil
+}
+
+// updateStatus writes the state of the watcher into the status of the given config, if it changed.
+// Nothing is written if the watcher has to wait for leadership, see WaitForLeadership.
+func (w *ConfigWatcher) updateStatus(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig) error {
+	w.lock.RLock()
+	leading := w.leading
+	w.lock.RUnlock()
+	if w.WaitForLeadership && !leading {
+		logging.FromContextOrPanic(ctx).Debug("Not the leader, skipping status update")
+		return nil
+	}
+
+	old := cfg.DeepCopy()
+	cond := metav1.Condition{
+		Type:               quotav1alpha1.ConfigConditionValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cfg.Generation,
+		Reason:             quotav1alpha1.ConfigReasonValid,
+		Message:            fmt.Sprintf("Generation %d is active.", cfg.Generation),
+	}
+	w.lock.RLock()
+	if w.err != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = quotav1alpha1.ConfigReasonValidationFailed
+		cond.Message = w.err.Error()
+	}
+	cfg.Status.ObservedGeneration = cfg.Generation
+	cfg.Status.ActiveGeneration = 0
+	if w.active != nil && w.active.UID == cfg.UID {
+		cfg.Status.ActiveGeneration = w.active.Generation
+		cfg.Status.Rollout = w.rolloutStatus(cfg.Status.Rollout)
+		if w.tracksResults {
+			meta.SetStatusCondition(&cfg.Status.Conditions, w.appliedCondition())
+			meta.SetStatusCondition(&cfg.Status.Conditions, w.inSyncCondition())
+		}
+	}
+	w.lock.RUnlock()
+	meta.SetStatusCondition(&cfg.Status.Conditions, cond)
+
+	if equality.Semantic.DeepEqual(old.Status, cfg.Status) {
+		return nil
+	}
+	if err := w.PlatformCluster.Client().Status().Patch(ctx, cfg, client.MergeFrom(old)); err != nil {
+		return fmt.Errorf("error updating status of QuotaServiceConfig '%s': %w", w.ProviderName, err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the watcher with the Manager.
+// The QuotaServiceConfig is watched on the platform cluster.
+// The watcher runs on all replicas, independent of leader election, because all of them need the config.
+// The status is only written by the leader, see StartStatusUpdates.
+// Additionally, the config is reconciled whenever the recorded config errors change.
+func (w *ConfigWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	w.WaitForLeadership = true
+	if err := mgr.Add(manager.RunnableFunc(w.StartStatusUpdates)); err != nil {
+		return fmt.Errorf("error adding status updates of the config watcher to the manager: %w", err)
+	}
+	needLeaderElection := false
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ConfigControllerName).
+		WithOptions(controller.Options{NeedLeaderElection: &needLeaderElection}).
+		WatchesRawSource(source.Kind(w.PlatformCluster.Cluster().GetCache(), &quotav1alpha1.QuotaServiceConfig{}, &handler.TypedEnqueueRequestForObject[*quotav1alpha1.QuotaServiceConfig]{}, ctrlutils.ToTypedPredicate[*quotav1alpha1.QuotaServiceConfig](ctrlutils.ExactNamePredicate(w.ProviderName, "")))).
+		WatchesRawSource(source.Channel(w.statusTrigger, handler.TypedEnqueueRequestsFromMapFunc(func(_ context.Context, _ *quotav1alpha1.QuotaServiceConfig) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: w.ProviderName}}}
+		}))).
+		Complete(w)
+}
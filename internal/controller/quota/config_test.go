@@ -0,0 +1,147 @@
+package quota_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Config Watcher", func() {
+
+	var env *testutils.ComplexEnvironment
+	var watcher *quotacontroller.ConfigWatcher
+
+	getConfig := func() *quotav1alpha1.QuotaServiceConfig {
+		cfg := &quotav1alpha1.QuotaServiceConfig{}
+		cfg.SetName(providerName)
+		ExpectWithOffset(1, env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+		return cfg
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		watcher = env.Reconciler(rec).(*quotacontroller.QuotaController).Configs
+	})
+
+	It("should activate a valid config and report it in the status", func() {
+		cfg := getConfig()
+		Expect(watcher.Active()).ToNot(BeNil())
+		Expect(watcher.Active().Generation).To(Equal(cfg.Generation))
+		Expect(watcher.Check(nil)).To(Succeed())
+
+		Expect(cfg.Status.ObservedGeneration).To(Equal(cfg.Generation))
+		Expect(cfg.Status.ActiveGeneration).To(Equal(cfg.Generation))
+		Expect(meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionValid)).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Status":             Equal(metav1.ConditionTrue),
+			"Reason":             Equal(quotav1alpha1.ConfigReasonValid),
+			"ObservedGeneration": Equal(cfg.Generation),
+		})))
+	})
+
+	It("should keep the last valid config if a new generation is invalid", func() {
+		activeGeneration := getConfig().Generation
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = "invalid"
+		})
+
+		cfg := getConfig()
+		Expect(watcher.Active().Generation).To(Equal(activeGeneration))
+		Expect(watcher.Check(nil)).To(MatchError(ContainSubstring("keeping generation %d active", activeGeneration)))
+		Expect(cfg.Status.ObservedGeneration).To(Equal(cfg.Generation))
+		Expect(cfg.Status.ActiveGeneration).To(Equal(activeGeneration))
+		Expect(meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionValid)).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Status":  Equal(metav1.ConditionFalse),
+			"Reason":  Equal(quotav1alpha1.ConfigReasonValidationFailed),
+			"Message": ContainSubstring("generation %d", cfg.Generation),
+		})))
+
+		// namespaces are still reconciled with the last valid config
+		ns := &corev1.Namespace{}
+		ns.SetName("ns-normal")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		rql := &corev1.ResourceQuotaList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, rql, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(rql.Items).ToNot(BeEmpty())
+
+		// fixing the config activates it again
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = quotav1alpha1.CUMULATIVE
+		})
+		cfg = getConfig()
+		Expect(watcher.Active().Generation).To(Equal(cfg.Generation))
+		Expect(watcher.Check(nil)).To(Succeed())
+		Expect(cfg.Status.ActiveGeneration).To(Equal(cfg.Generation))
+		Expect(meta.IsStatusConditionTrue(cfg.Status.Conditions, quotav1alpha1.ConfigConditionValid)).To(BeTrue())
+	})
+
+	It("should notify subscribers only if a new generation becomes active", func() {
+		events := watcher.Subscribe()
+		cfg := getConfig()
+
+		// same generation again
+		env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+		Expect(events).ToNot(Receive())
+
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = "invalid"
+		})
+		Expect(events).ToNot(Receive())
+
+		// only the latest active generation is buffered
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = quotav1alpha1.MAXIMUM
+		})
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = quotav1alpha1.CUMULATIVE
+		})
+		cfg = getConfig()
+		Expect(events).To(Receive(MatchFields(IgnoreExtras, Fields{
+			"Object": PointTo(MatchFields(IgnoreExtras, Fields{
+				"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+					"Generation": Equal(cfg.Generation),
+				}),
+			})),
+		})))
+		Expect(events).ToNot(Receive())
+	})
+
+	It("should load new generations, but only write the status once it is the leader, if it waits for leadership", func() {
+		watcher.WaitForLeadership = true
+		activeGeneration := getConfig().Generation
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Quotas[0].Mode = quotav1alpha1.MAXIMUM
+		})
+		cfg := getConfig()
+		Expect(watcher.Active().Generation).To(Equal(cfg.Generation))
+		Expect(cfg.Status.ActiveGeneration).To(Equal(activeGeneration))
+
+		Expect(watcher.StartStatusUpdates(env.Ctx)).To(Succeed())
+		env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+		cfg = getConfig()
+		Expect(cfg.Status.ObservedGeneration).To(Equal(cfg.Generation))
+		Expect(cfg.Status.ActiveGeneration).To(Equal(cfg.Generation))
+		Expect(meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionValid)).To(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Status":             Equal(metav1.ConditionTrue),
+			"ObservedGeneration": Equal(cfg.Generation),
+		})))
+	})
+
+	It("should keep the last valid config if the config is deleted", func() {
+		cfg := getConfig()
+		Expect(env.Client(platformCluster).Delete(env.Ctx, cfg)).To(Succeed())
+		env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+
+		Expect(watcher.Active()).ToNot(BeNil())
+		Expect(watcher.Check(nil)).To(MatchError(ContainSubstring("not found")))
+	})
+
+})
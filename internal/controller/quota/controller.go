@@ -6,18 +6,20 @@ import (
 	"fmt"
 	"maps"
 	"strings"
-	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -40,92 +42,158 @@ func NewQuotaController(platformCluster, onboardingCluster *clusters.Cluster, pr
 		PlatformCluster:   platformCluster,
 		OnboardingCluster: onboardingCluster,
 		ProviderName:      providerName,
-		cfgLock:           &sync.RWMutex{},
+		Configs:           NewConfigWatcher(platformCluster, providerName),
+		ConfigRolloutRate: DefaultConfigRolloutRate,
+		HistoryLimit:      DefaultHistoryLimit,
 	}
 }
 
-// QuotaController actually reconciles namespaces, but it gets triggered by generation changes of
-// - ResourceQuotas with an OwnerReference pointing to the namespace
-// - QuotaIncreases in the namespace
+// QuotaController actually reconciles namespaces, but it gets triggered by
+// - spec, label and annotation changes of ResourceQuotas and LimitRanges with an OwnerReference pointing to the namespace
+// - generation changes of QuotaIncreases in the namespace
 type QuotaController struct {
 	PlatformCluster   *clusters.Cluster
 	OnboardingCluster *clusters.Cluster
 	ProviderName      string
-	Config            *quotav1alpha1.QuotaServiceConfig
-	cfgLock           *sync.RWMutex
+	// Configs provides the active QuotaServiceConfig.
+	Configs *ConfigWatcher
+	// ErrorRate records the results of all reconciles. Optional.
+	ErrorRate *ErrorRateTracker
+	// ConfigRolloutRate is the number of namespaces per second which are enqueued after a config change. 0 means no limit.
+	ConfigRolloutRate float64
+	// OnboardingCache is used to read namespaces, QuotaIncreases, ResourceQuotas and LimitRanges from the onboarding cluster. Optional.
+	// If nil, they are read directly from the API server via the onboarding cluster's client.
+	// The cache must not strip the managed fields, because they are required to detect drift.
+	OnboardingCache client.Reader
+	// MaxConcurrentReconciles is the number of namespaces, or MCP clusters for the MCP controller, which are reconciled in parallel.
+	// 0 means the default of controller-runtime, which is 1.
+	MaxConcurrentReconciles int
+	// BackoffBaseDelay and BackoffMaxDelay configure the per-item exponential backoff after failed reconciles.
+	// If both are 0, the default rate limiter of controller-runtime is used.
+	BackoffBaseDelay time.Duration
+	BackoffMaxDelay  time.Duration
+	// AuditLog enables the audit mode, if set. Optional.
+	// In audit mode, nothing is written to the reconciled clusters, except for events. The changes which would have been performed are recorded in the AuditLog instead.
+	AuditLog *AuditLog
+	// HistoryLimit is the number of changes of the generated ResourceQuota which are kept in the history ConfigMap of each namespace.
+	// 0 disables the history ConfigMap, the changes are still logged.
+	HistoryLimit int
 }
 
 // Reconcile contains the main logic of creating and updating a ResourceQuota based on the QuotaIncreases in the reconciled Namespace.
 // The Namespace is registered as controller of the ResourceQuota and reacts on changes to QuotaIncreases within the namespace (even without owner reference), so this gets triggered if either is modified.
+// Errors are mapped to the requeue behavior according to their class, see reconcileResult.
 func (r *QuotaController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	log := logging.FromContextOrPanic(ctx).WithName(ControllerName)
 	ctx = logging.NewContext(ctx, log)
 	log.Debug("Reconcile triggered")
 
-	// fetch and update internal config
-	cfg := &quotav1alpha1.QuotaServiceConfig{}
-	if err := r.PlatformCluster.Client().Get(ctx, types.NamespacedName{Name: r.ProviderName}, cfg); err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to fetch QuotaServiceConfig '%s': %w", r.ProviderName, err)
-	}
-	if err := cfg.Spec.Validate(); err != nil {
-		return ctrl.Result{}, fmt.Errorf("invalid QuotaServiceConfig '%s': %w", r.ProviderName, err)
-	}
-	// if the config has a different generation than the known one, which indicates a spec change, update the internal config
-	r.cfgLock.RLock()
-	knownGeneration := int64(-1)
-	if r.Config != nil {
-		knownGeneration = r.Config.Generation
-	}
-	r.cfgLock.RUnlock()
-	if cfg.Generation != knownGeneration {
-		log.Info("Detected change in QuotaServiceConfig, updating internal config", "oldGeneration", knownGeneration, "newGeneration", cfg.Generation)
-		r.cfgLock.Lock()
-		r.Config = cfg
-		r.cfgLock.Unlock()
+	err := r.reconcile(ctx, req)
+	return r.reconcileResult(ctx, ControllerName, fmt.Sprintf("namespace '%s'", req.Name), ctrl.Result{}, err, 0)
+}
+
+// reconcile fetches the namespace and applies the matching quota definition to it.
+func (r *QuotaController) reconcile(ctx context.Context, req reconcile.Request) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	if r.Configs.Active() == nil {
+		// all namespaces are reconciled as soon as the config watcher has loaded a valid config
+		log.Debug("No valid QuotaServiceConfig loaded yet, skipping reconciliation")
+		return nil
 	}
 
 	// fetch Namespace
 	ns := &corev1.Namespace{}
-	if err := r.OnboardingCluster.Client().Get(ctx, req.NamespacedName, ns); err != nil {
+	if err := r.reader(r.OnboardingCluster).Get(ctx, req.NamespacedName, ns); err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Debug("Namespace not found")
-			return ctrl.Result{}, nil
+			r.forgetAuditChanges(r.OnboardingCluster, req.Name)
+			r.forgetDrift(r.OnboardingCluster, req.Name)
+			return nil
 		}
-		return ctrl.Result{}, fmt.Errorf("unable to fetch Namespace: %w", err)
+		return fmt.Errorf("unable to fetch Namespace: %w", err)
 	}
 
 	// identify responsible quota definition
-	var qdef *quotav1alpha1.QuotaDefinition
-	r.cfgLock.RLock()
-	for _, qd := range r.Config.Spec.Quotas {
-		if qd.Selector == nil {
-			qdef = qd.DeepCopy()
-			break
-		} else {
-			sel, err := metav1.LabelSelectorAsSelector(qd.Selector)
-			if err != nil {
-				r.cfgLock.RUnlock()
-				return ctrl.Result{}, fmt.Errorf("error converting label selector for quota definition '%s': %w", qd.Name, err)
-			}
-			if sel.Matches(labels.Set(ns.Labels)) {
-				qdef = qd.DeepCopy()
-				break
-			}
-		}
+	qdef, err := r.findQuotaDefinition(ns, onboardingTarget)
+	if err != nil {
+		return NewConfigError(err)
 	}
-	r.cfgLock.RUnlock()
 	if qdef == nil {
 		log.Debug("No matching quota definition found for namespace, skipping reconciliation")
-		return ctrl.Result{}, nil
-	} else {
-		log = log.WithName(qdef.Name).WithValues("quotaDefinition", qdef.Name)
-		ctx = logging.NewContext(ctx, log)
-		log.Debug("Found matching quota definition for namespace")
+		r.forgetAuditChanges(r.OnboardingCluster, ns.Name)
+		r.forgetDrift(r.OnboardingCluster, ns.Name)
+		return nil
+	}
+
+	return r.reconcileNamespace(ctx, r.OnboardingCluster, ns, qdef)
+}
+
+// reader returns the reader for namespaces, QuotaIncreases, ResourceQuotas and LimitRanges in the given cluster.
+// This is the OnboardingCache for the onboarding cluster, if configured, and the cluster's client otherwise.
+func (r *QuotaController) reader(tgt *clusters.Cluster) client.Reader {
+	if r.OnboardingCache != nil && tgt == r.OnboardingCluster {
+		return r.OnboardingCache
+	}
+	return tgt.Client()
+}
+
+// findQuotaDefinition returns a copy of the first quota definition from the config for the namespace which passes the filter and whose selector matches the namespace.
+// During a staged rollout, this is the stable config for namespaces which have not been updated yet.
+// Returns nil if no quota definition matches.
+func (r *QuotaController) findQuotaDefinition(ns *corev1.Namespace, filter func(qd *quotav1alpha1.QuotaDefinition) bool) (*quotav1alpha1.QuotaDefinition, error) {
+	qdef, err := matchQuotaDefinition(r.Configs.compiledSelectors(), r.Configs.ForNamespace(ns).Spec.Quotas, ns, filter)
+	if err != nil {
+		return nil, err
+	}
+	return qdef.DeepCopy(), nil
+}
+
+// onboardingTarget is a quota definition filter which only lets quota definitions with target 'onboarding' pass.
+func onboardingTarget(qd *quotav1alpha1.QuotaDefinition) bool {
+	return qd.GetTarget() == quotav1alpha1.TARGET_ONBOARDING
+}
+
+// matchQuotaDefinition returns the first quota definition from the given list which passes the filter and whose selector matches the namespace.
+// The selectors are taken from the given cache, if possible.
+// A nil filter lets all quota definitions pass. Returns nil if no quota definition matches.
+func matchQuotaDefinition(selectors *selectorCache, qdefs []*quotav1alpha1.QuotaDefinition, ns *corev1.Namespace, filter func(qd *quotav1alpha1.QuotaDefinition) bool) (*quotav1alpha1.QuotaDefinition, error) {
+	for _, qd := range qdefs {
+		if filter != nil && !filter(qd) {
+			continue
+		}
+		sel, err := selectors.namespaceSelector(qd)
+		if err != nil {
+			return nil, err
+		}
+		if sel.Matches(labels.Set(ns.Labels)) {
+			return qd, nil
+		}
 	}
+	return nil, nil
+}
+
+// reconcileNamespace applies the given quota definition to the namespace.
+// The namespace is expected to belong to the given cluster, which is used for all reads and writes.
+// In audit mode, the changes which would have been performed are recorded instead.
+func (r *QuotaController) reconcileNamespace(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition) error {
+	log := logging.FromContextOrPanic(ctx).WithName(qdef.Name).WithValues("quotaDefinition", qdef.Name)
+	ctx = logging.NewContext(ctx, log)
+	log.Debug("Found matching quota definition for namespace")
+
+	return r.auditNamespaceChanges(ctx, tgt, ns, func(ctx context.Context) error {
+		return r.applyQuotaDefinition(ctx, tgt, ns, qdef)
+	})
+}
+
+// applyQuotaDefinition contains the actual logic of reconcileNamespace.
+func (r *QuotaController) applyQuotaDefinition(ctx context.Context, tgt *clusters.Cluster, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition) error {
+	log := logging.FromContextOrPanic(ctx)
 
 	if !ns.DeletionTimestamp.IsZero() {
 		log.Debug("Namespace is being deleted, no action required")
-		return ctrl.Result{}, nil
+		r.forgetDrift(tgt, ns.Name)
+		return nil
 	}
 
 	log.Info("Starting actual reconciliation logic")
@@ -134,45 +202,75 @@ func (r *QuotaController) Reconcile(ctx context.Context, req reconcile.Request)
 	quotaManagedBy, ok := ctrlutils.GetLabel(ns, quotav1alpha1.ManagedByLabel)
 	if ok && quotaManagedBy != r.ProviderName {
 		log.Info("Namespace is managed by another instance of this platform service, skipping reconciliation", "providerName", quotaManagedBy)
-		return ctrl.Result{}, nil
+		r.forgetDrift(tgt, ns.Name)
+		return nil
 	}
 
 	// ensure labels on namespace
-	old := ns.DeepCopy()
-	if err := ctrlutils.EnsureLabel(ctx, nil, ns, quotav1alpha1.ManagedByLabel, r.ProviderName, false); err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to set managed-by label '%s' to value '%s' on namespace: %w", quotav1alpha1.ManagedByLabel, r.ProviderName, err)
-	}
-	if err := ctrlutils.EnsureLabel(ctx, nil, ns, quotav1alpha1.BaseQuotaLabel, qdef.Name, false, ctrlutils.OVERWRITE); err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to set base quota label '%s' to value '%s' on namespace: %w", quotav1alpha1.BaseQuotaLabel, qdef.Name, err)
+	desiredLabels := map[string]string{
+		quotav1alpha1.ManagedByLabel:                  r.ProviderName,
+		quotav1alpha1.BaseQuotaLabel:                  qdef.Name,
+		quotav1alpha1.QuotaIncreaseOperationModeLabel: string(qdef.Mode),
 	}
-	if err := ctrlutils.EnsureLabel(ctx, nil, ns, quotav1alpha1.QuotaIncreaseOperationModeLabel, string(qdef.Mode), false, ctrlutils.OVERWRITE); err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to set operation mode label '%s' to value '%s' on namespace: %w", quotav1alpha1.QuotaIncreaseOperationModeLabel, qdef.Mode, err)
+	oldLabels := maps.Clone(ns.Labels)
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
 	}
-	if !maps.Equal(old.Labels, ns.Labels) {
-		if err := r.OnboardingCluster.Client().Patch(ctx, ns, client.MergeFrom(old)); err != nil {
-			return ctrl.Result{}, fmt.Errorf("error patching labels on namespace: %w", err)
+	maps.Copy(ns.Labels, desiredLabels)
+	if !maps.Equal(oldLabels, ns.Labels) {
+		change := &AuditChange{Kind: "Namespace", Name: ns.Name, Action: AuditActionUpdate, Details: strings.Join(labelChanges(desiredLabels, oldLabels), ", ")}
+		if err := r.write(ctx, change, func() error {
+			if err := r.apply(ctx, tgt, ns, corev1ac.Namespace(ns.Name).WithLabels(desiredLabels), "Namespace", ns.Name); err != nil {
+				return fmt.Errorf("error applying labels on namespace: %w", err)
+			}
+			log.Info("Updated labels on namespace", "oldLabels", oldLabels, "newLabels", ns.Labels)
+			return nil
+		}); err != nil {
+			return err
 		}
-		log.Info("Updated labels on namespace", "oldLabels", old.Labels, "newLabels", ns.Labels)
 	}
 
 	// list all QuotaIncreases in namespace
 	qis := &quotav1alpha1.QuotaIncreaseList{}
-	if err := r.OnboardingCluster.Client().List(ctx, qis, client.InNamespace(ns.Name)); err != nil {
-		return ctrl.Result{}, fmt.Errorf("error listing QuotaIncreases: %w", err)
+	if err := r.reader(tgt).List(ctx, qis, client.InNamespace(ns.Name)); err != nil {
+		if !r.auditing() || !meta.IsNoMatchError(err) {
+			return fmt.Errorf("error listing QuotaIncreases: %w", err)
+		}
+		// in audit mode, the QuotaIncrease CRD is not installed in MCP clusters, so there can't be any QuotaIncreases
+		log.Debug("QuotaIncrease CRD not found, assuming that there are no QuotaIncreases")
+	}
+
+	// take over, convert or ignore pre-existing ResourceQuotas according to the adoption policy
+	adoption, err := r.adoptResourceQuotas(ctx, tgt, ns, qdef, qis)
+	if err != nil {
+		return fmt.Errorf("error adopting ResourceQuotas: %w", err)
 	}
 
+	// reject QuotaIncreases which exceed the configured limits
+	acceptedQis, rejections := r.applyQuotaIncreaseLimits(ctx, ns, qdef, qis)
+
 	// create/update ResourceQuota
-	_, effects, err := r.createOrUpdateResourceQuota(ctx, ns, qdef, qis)
+	rq, effects, err := r.createOrUpdateResourceQuota(ctx, tgt, ns, qdef, acceptedQis)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("error creating/updating ResourceQuota: %w", err)
+		return fmt.Errorf("error applying ResourceQuota: %w", err)
+	}
+
+	// delete the ResourceQuotas which have been replaced by the generated one
+	if err := r.finishAdoption(ctx, tgt, ns, qdef, rq, adoption); err != nil {
+		return fmt.Errorf("error adopting ResourceQuotas: %w", err)
+	}
+
+	// create/update/delete LimitRange
+	if err := r.reconcileLimitRange(ctx, tgt, ns, qdef, rq); err != nil {
+		return fmt.Errorf("error reconciling LimitRange: %w", err)
 	}
 
 	// ensure QuotaIncrease integrity
-	if err := r.evaluateEffectiveness(ctx, ns, qdef, qis, effects); err != nil {
-		return ctrl.Result{}, fmt.Errorf("error evaluating QuotaIncrease effectiveness: %w", err)
+	if err := r.evaluateEffectiveness(ctx, tgt, ns, qdef, qis, effects, rejections); err != nil {
+		return fmt.Errorf("error evaluating QuotaIncrease effectiveness: %w", err)
 	}
 
-	return ctrl.Result{}, nil
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -199,67 +297,132 @@ func (r *QuotaController) SetupWithManager(mgr ctrl.Manager) error {
 				),
 			),
 		)).
-		Owns(&corev1.ResourceQuota{}, builder.WithPredicates(
-			predicate.And(
-				predicate.GenerationChangedPredicate{},
-				predicate.Not(
-					predicate.And(
-						ctrlutils.HasLabelPredicate(quotav1alpha1.ManagedByLabel, ""),
-						predicate.Not(
-							ctrlutils.HasLabelPredicate(quotav1alpha1.ManagedByLabel, r.ProviderName),
-						),
-					),
-				),
-			),
-		)).
+		Owns(&corev1.ResourceQuota{}, builder.WithPredicates(r.OwnedObjectPredicate())).
+		Owns(&corev1.LimitRange{}, builder.WithPredicates(r.OwnedObjectPredicate())).
 		Watches(&quotav1alpha1.QuotaIncrease{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
 			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: o.GetNamespace()}}}
 		}), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		WatchesRawSource(source.Kind(r.PlatformCluster.Cluster().GetCache(), &quotav1alpha1.QuotaServiceConfig{}, handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig) []reconcile.Request {
-			// simply reconcile all namespaces
-			// We could optimize this by first fetching the changed config and then listing only those namespace which match a selector,
-			// but this would cause duplicate code and significantly complicate this logic,
-			// and since the main reconciliation loop anyway ignores namespaces that don't match a selector, the effect would be negligible.
-			nsList := &corev1.NamespaceList{}
-			if err := r.OnboardingCluster.Client().List(ctx, nsList); err != nil {
-				logging.FromContextOrDiscard(ctx).Error(err, "Error listing namespaces for QuotaServiceConfig change")
-				return nil
-			}
-			reqs := make([]reconcile.Request, len(nsList.Items))
-			for i, ns := range nsList.Items {
-				reqs[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}}
-			}
-			return reqs
-		}), ctrlutils.ToTypedPredicate[*quotav1alpha1.QuotaServiceConfig](ctrlutils.ExactNamePredicate(r.ProviderName, "")), predicate.TypedGenerationChangedPredicate[*quotav1alpha1.QuotaServiceConfig]{})).
+		WatchesRawSource(source.Channel(r.Configs.Subscribe(), r.ConfigChangeHandler())).
+		WithOptions(r.controllerOptions()).
 		Complete(r)
 }
 
-func (r *QuotaController) createOrUpdateResourceQuota(ctx context.Context, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList) (*corev1.ResourceQuota, map[string]corev1.ResourceList, error) {
+// OwnedObjectPredicate filters the events for the ResourceQuotas and LimitRanges generated by this controller.
+// Only objects which are not managed by another provider are considered. Updates are only relevant if the spec, the labels or the annotations have changed.
+// The generation can't be used for this, because it is not bumped by the API server for these kinds, and the status of ResourceQuotas changes with every object created in the namespace.
+func (r *QuotaController) OwnedObjectPredicate() predicate.Predicate {
+	return predicate.And(
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return ownedObjectChanged(e.ObjectOld, e.ObjectNew)
+			},
+		},
+		predicate.Not(
+			predicate.And(
+				ctrlutils.HasLabelPredicate(quotav1alpha1.ManagedByLabel, ""),
+				predicate.Not(
+					ctrlutils.HasLabelPredicate(quotav1alpha1.ManagedByLabel, r.ProviderName),
+				),
+			),
+		),
+	)
+}
+
+// ownedObjectChanged returns true if the spec, the labels or the annotations differ between the given versions of a ResourceQuota or LimitRange.
+func ownedObjectChanged(oldObj, newObj client.Object) bool {
+	if oldObj == nil || newObj == nil {
+		return true
+	}
+	if !equality.Semantic.DeepEqual(oldObj.GetLabels(), newObj.GetLabels()) || !equality.Semantic.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations()) {
+		return true
+	}
+	switch o := oldObj.(type) {
+	case *corev1.ResourceQuota:
+		n, ok := newObj.(*corev1.ResourceQuota)
+		return !ok || !equality.Semantic.DeepEqual(o.Spec, n.Spec)
+	case *corev1.LimitRange:
+		n, ok := newObj.(*corev1.LimitRange)
+		return !ok || !equality.Semantic.DeepEqual(o.Spec, n.Spec)
+	}
+	return oldObj.GetResourceVersion() != newObj.GetResourceVersion()
+}
+
+// createOrUpdateResourceQuota computes the ResourceQuota for the namespace and applies it via server-side apply.
+// Only the fields which are set by this controller are owned by it, labels and annotations added by other tools are preserved.
+// If the live ResourceQuota deviates from the computed one, the drift is reported and - depending on the drift policy of the namespace - corrected.
+// Uncorrected drift is recorded for the 'InSync' condition of the config.
+func (r *QuotaController) createOrUpdateResourceQuota(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList) (*corev1.ResourceQuota, map[string]corev1.ResourceList, error) {
 	log := logging.FromContextOrPanic(ctx)
 
-	computedRq, effects := r.computeResourceQuota(ctx, namespace, qdef, qis)
+	rq, effects := r.computeResourceQuota(ctx, namespace, qdef, qis)
 
-	rq := &corev1.ResourceQuota{}
-	rq.SetName(computedRq.Name)
-	rq.SetNamespace(computedRq.Namespace)
-	log.Info("Creating/Updating ResourceQuota", "resourceQuota", rq.Name)
-	_, err := controllerutil.CreateOrUpdate(ctx, r.OnboardingCluster.Client(), rq, func() error {
-		rq.Annotations = computedRq.Annotations
-		rq.Labels = computedRq.Labels
-		rq.Spec = computedRq.Spec
+	// detect drift
+	live := &corev1.ResourceQuota{}
+	if err := r.reader(tgt).Get(ctx, client.ObjectKeyFromObject(rq), live); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("unable to fetch ResourceQuota: %w", err)
+		}
+		live = nil
+	}
+	// With the 'reportOnly' drift policy, all changes of the desired state are applied, except for the drifted fields.
+	applied := rq
+	var uncorrected *resourceQuotaDrift
+	// an unmanaged ResourceQuota with the same name is taken over as required by the adoption policy, its deviations are not drift
+	if live != nil && !isUnmanagedResourceQuota(live) {
+		if drift := computeDrift(rq, live, r.FieldManager()); drift != nil {
+			policy := driftPolicy(namespace)
+			oldDrift, _ := ctrlutils.GetAnnotation(live, quotav1alpha1.DriftAnnotation)
+			if oldDrift != drift.String() && !r.auditing() {
+				// don't report the same uncorrected drift multiple times
+				// In audit mode, the drift annotation is not written, so the drift is only reported as part of the skipped changes, which are deduplicated.
+				log.Info("Detected drift of ResourceQuota", "resourceQuota", live.Name, "drift", drift.String(), "policy", policy)
+				r.recordEvent(ctx, tgt, live, corev1.EventTypeWarning, "ResourceQuotaDrift", fmt.Sprintf("ResourceQuota deviates from the desired state (policy: %s): %s", policy, drift.String()))
+				resourceQuotaDriftTotal.WithLabelValues(qdef.Name, policy).Inc()
+			}
+			if policy == quotav1alpha1.DriftPolicyReportOnly {
+				if oldDrift != drift.String() {
+					change := &AuditChange{Kind: "ResourceQuota", Name: live.Name, Action: AuditActionUpdate, Details: fmt.Sprintf("annotate drift: %s", drift.String())}
+					if err := r.write(ctx, change, func() error {
+						return ctrlutils.EnsureAnnotation(ctx, tgt.Client(), live, quotav1alpha1.DriftAnnotation, drift.String(), true, ctrlutils.OVERWRITE)
+					}); err != nil {
+						return nil, nil, fmt.Errorf("error setting drift annotation on ResourceQuota: %w", err)
+					}
+				}
+				uncorrected = drift
+				applied = withoutDriftedFields(rq, drift)
+			}
+		}
+	}
+	r.Configs.RecordDrift(tgt.ID(), namespace.Name, uncorrected.String())
 
-		return controllerutil.SetControllerReference(namespace, rq, r.OnboardingCluster.Scheme())
-	})
+	owner, err := controllerReference(tgt, namespace)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := r.write(ctx, resourceQuotaChange(applied, live), func() error {
+		log.Info("Applying ResourceQuota", "resourceQuota", rq.Name)
+		if err := r.apply(ctx, tgt, namespace, resourceQuotaApplyConfiguration(applied).WithOwnerReferences(owner), "ResourceQuota", rq.Name); err != nil {
+			return err
+		}
+		r.recordHistory(ctx, tgt, namespace, qdef, applied, live, effects)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	if uncorrected == nil && live != nil && ctrlutils.HasAnnotation(live, quotav1alpha1.DriftAnnotation) {
+		change := &AuditChange{Kind: "ResourceQuota", Name: live.Name, Action: AuditActionUpdate, Details: "remove drift annotation"}
+		if err := r.write(ctx, change, func() error {
+			return ctrlutils.EnsureAnnotation(ctx, tgt.Client(), live, quotav1alpha1.DriftAnnotation, "", true, ctrlutils.DELETE)
+		}); err != nil {
+			return nil, nil, fmt.Errorf("error removing drift annotation from ResourceQuota: %w", err)
+		}
+	}
 	return rq, effects, nil
 }
 
 // computeResourceQuota takes the base ResourceQuota from the config and returns it with the quotas adapted based on the QuotaIncreases in the namespace, respecting the configured mode.
+// The ResourceQuota is annotated with the provenance of its hard limits.
 func (r *QuotaController) computeResourceQuota(ctx context.Context, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList) (*corev1.ResourceQuota, map[string]corev1.ResourceList) {
-	log := logging.FromContextOrPanic(ctx)
-
 	rq := qdef.BaseResourceQuota()
 	rq.SetNamespace(namespace.Name)
 	if rq.Labels == nil {
@@ -268,6 +431,21 @@ func (r *QuotaController) computeResourceQuota(ctx context.Context, namespace *c
 	rq.Labels[quotav1alpha1.ManagedByLabel] = r.ProviderName
 	rq.Labels[quotav1alpha1.QuotaDefinitionLabel] = qdef.Name
 
+	base := rq.Spec.Hard.DeepCopy()
+	effects := applyQuotaIncreases(ctx, namespace, qdef, qis, rq)
+
+	if rq.Annotations == nil {
+		rq.Annotations = map[string]string{}
+	}
+	rq.Annotations[quotav1alpha1.ProvenanceAnnotation] = resourceQuotaProvenance(qdef, base, rq.Spec.Hard, effects).String()
+	return rq, effects
+}
+
+// applyQuotaIncreases adapts the hard limits of the given ResourceQuota based on the QuotaIncreases, respecting the configured mode.
+// It returns the effect of each QuotaIncrease, keyed by name.
+func applyQuotaIncreases(ctx context.Context, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList, rq *corev1.ResourceQuota) map[string]corev1.ResourceList {
+	log := logging.FromContextOrPanic(ctx)
+
 	effects := map[string]corev1.ResourceList{}
 
 	switch qdef.Mode {
@@ -275,7 +453,7 @@ func (r *QuotaController) computeResourceQuota(ctx context.Context, namespace *c
 		qiName, ok := ctrlutils.GetLabel(namespace, quotav1alpha1.SingularQuotaIncreaseLabel)
 		if !ok {
 			log.Info("No singular QuotaIncrease label found on namespace, ignoring QuotaIncreases", "label", quotav1alpha1.SingularQuotaIncreaseLabel)
-			return rq, effects
+			return effects
 		}
 		for _, qi := range qis.Items {
 			if qi.Name == qiName {
@@ -286,7 +464,7 @@ func (r *QuotaController) computeResourceQuota(ctx context.Context, namespace *c
 						effects[qi.Name][name] = quantity
 					}
 				}
-				return rq, effects
+				return effects
 			}
 		}
 		log.Info("Referenced QuotaIncrease not found in namespace", "label", quotav1alpha1.SingularQuotaIncreaseLabel, "QuotaIncrease", qiName)
@@ -314,7 +492,7 @@ func (r *QuotaController) computeResourceQuota(ctx context.Context, namespace *c
 			rq.Spec.Hard[resource] = qi.Spec.Hard[resource]
 		}
 	}
-	return rq, effects
+	return effects
 }
 
 // computeMaxQuotaMapping maps resources to the quota increases which provide the highest quantity for these resources, respectively.
@@ -349,39 +527,125 @@ func effectAsString(data corev1.ResourceList) string {
 	return res
 }
 
-// evaluateEffectiveness is responsible for setting the effect annotation on all QuotaIncrease resources.
-// If deletion of ineffective QuotaIncreases is enabled, it will also delete QuotaIncreases that are no longer effective.
-func (r *QuotaController) evaluateEffectiveness(ctx context.Context, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList, effects map[string]corev1.ResourceList) error {
-	log := logging.FromContextOrPanic(ctx)
+// quotaIncreaseAction describes what happens to a QuotaIncrease after the ResourceQuota has been computed.
+type quotaIncreaseAction int
+
+const (
+	// quotaIncreaseActionAnnotate means that the effect annotation of the QuotaIncrease is updated.
+	quotaIncreaseActionAnnotate quotaIncreaseAction = iota
+	// quotaIncreaseActionDelete means that the QuotaIncrease is deleted.
+	quotaIncreaseActionDelete
+	// quotaIncreaseActionNone means that the QuotaIncrease is left untouched.
+	quotaIncreaseActionNone
+)
 
-	singularQIName := ""
-	prefix := ""
+// quotaIncreaseOutcome determines what happens to the QuotaIncrease with the given name, based on its effect and a potential rejection.
+// For quotaIncreaseActionAnnotate, the returned string is the value for the effect annotation, for quotaIncreaseActionDelete it is the reason for the deletion.
+func quotaIncreaseOutcome(qdef *quotav1alpha1.QuotaDefinition, namespace *corev1.Namespace, qiName string, effects map[string]corev1.ResourceList, rejections map[string]string) (quotaIncreaseAction, string) {
+	isSingular := false
 	if qdef.Mode == quotav1alpha1.SINGULAR {
-		singularQIName, _ = ctrlutils.GetLabel(namespace, quotav1alpha1.SingularQuotaIncreaseLabel)
-		prefix = quotav1alpha1.ActiveSingularQuotaIncreaseEffectPrefix
+		singularQIName, _ := ctrlutils.GetLabel(namespace, quotav1alpha1.SingularQuotaIncreaseLabel)
+		isSingular = qiName == singularQIName
+	}
+
+	if reason, rejected := rejections[qiName]; rejected {
+		if qdef.QuotaIncreaseLimits.DeleteRejected && !isSingular {
+			// delete rejected QuotaIncrease, if it is not the selected 'singular' one
+			return quotaIncreaseActionDelete, reason
+		}
+		return quotaIncreaseActionAnnotate, fmt.Sprintf("%s %s", quotav1alpha1.RejectedQuotaIncreaseEffectPrefix, reason)
+	}
+	effect := effects[qiName]
+	if !qdef.DeleteIneffectiveQuotas || len(effect) > 0 {
+		effectString := effectAsString(effect)
+		if isSingular {
+			if effectString == "" {
+				effectString = quotav1alpha1.ActiveSingularQuotaIncreaseEffectPrefix
+			} else {
+				effectString = fmt.Sprintf("%s %s", quotav1alpha1.ActiveSingularQuotaIncreaseEffectPrefix, effectString)
+			}
+		}
+		return quotaIncreaseActionAnnotate, effectString
+	}
+	if !isSingular {
+		// delete QuotaIncrease, if it is not the selected 'singular' one
+		return quotaIncreaseActionDelete, "ineffective"
 	}
+	return quotaIncreaseActionNone, ""
+}
+
+// evaluateEffectiveness is responsible for setting the effect annotation on all QuotaIncrease resources.
+// If deletion of ineffective QuotaIncreases is enabled, it will also delete QuotaIncreases that are no longer effective.
+// QuotaIncreases contained in the rejections map are marked as rejected instead, or deleted if deletion of rejected QuotaIncreases is enabled.
+func (r *QuotaController) evaluateEffectiveness(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList, effects map[string]corev1.ResourceList, rejections map[string]string) error {
+	log := logging.FromContextOrPanic(ctx)
 
 	var errs error
 	for _, qi := range qis.Items {
-		effect := effects[qi.Name]
-		if !qdef.DeleteIneffectiveQuotas || len(effect) > 0 {
-			// patch effect annotation on QuotaIncrease
-			effectString := effectAsString(effect)
-			if qdef.Mode == quotav1alpha1.SINGULAR && qi.Name == singularQIName {
-				if effectString == "" {
-					effectString = prefix
-				} else {
-					effectString = fmt.Sprintf("%s %s", prefix, effectString)
-				}
-			}
-			errs = errors.Join(errs, ctrlutils.EnsureAnnotation(ctx, r.OnboardingCluster.Client(), &qi, quotav1alpha1.EffectAnnotation, effectString, true, ctrlutils.OVERWRITE))
-			errs = errors.Join(errs, ctrlutils.EnsureLabel(ctx, r.OnboardingCluster.Client(), &qi, quotav1alpha1.QuotaIncreaseOperationModeLabel, string(qdef.Mode), true, ctrlutils.OVERWRITE))
-		} else if qdef.Mode != quotav1alpha1.SINGULAR || qi.Name != singularQIName {
-			// delete QuotaIncrease, if it is not the selected 'singular' one
-			log.Info("Deleting ineffective QuotaIncrease", "quotaIncrease", client.ObjectKeyFromObject(&qi).String())
-			errs = errors.Join(errs, r.OnboardingCluster.Client().Delete(ctx, &qi))
+		action, value := quotaIncreaseOutcome(qdef, namespace, qi.Name, effects, rejections)
+		switch action {
+		case quotaIncreaseActionAnnotate:
+			// patch effect annotation and mode label on QuotaIncrease
+			details := quotaIncreaseEffect(qdef, namespace, &qi, effects, rejections)
+			errs = errors.Join(errs, r.patchQuotaIncrease(ctx, tgt, &qi, desiredQuotaIncrease(&qi, qdef, value, details)))
+		case quotaIncreaseActionDelete:
+			errs = errors.Join(errs, r.write(ctx, &AuditChange{Kind: "QuotaIncrease", Name: qi.Name, Action: AuditActionDelete, Details: fmt.Sprintf("reason: %s", value)}, func() error {
+				log.Info("Deleting QuotaIncrease", "quotaIncrease", client.ObjectKeyFromObject(&qi).String(), "reason", value)
+				return tgt.Client().Delete(ctx, &qi)
+			}))
 		}
 	}
 
 	return errs
 }
+
+// desiredQuotaIncrease returns a copy of the QuotaIncrease with the metadata maintained by the controller set to the given effect, its details and the mode of the quota definition.
+func desiredQuotaIncrease(qi *quotav1alpha1.QuotaIncrease, qdef *quotav1alpha1.QuotaDefinition, effect string, details *QuotaIncreaseEffect) *quotav1alpha1.QuotaIncrease {
+	desired := qi.DeepCopy()
+	if desired.Annotations == nil {
+		desired.Annotations = map[string]string{}
+	}
+	desired.Annotations[quotav1alpha1.EffectAnnotation] = effect
+	desired.Annotations[quotav1alpha1.EffectDetailsAnnotation] = details.String()
+	if desired.Labels == nil {
+		desired.Labels = map[string]string{}
+	}
+	desired.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel] = string(qdef.Mode)
+	return desired
+}
+
+// patchQuotaIncrease writes all differences between the current and the desired state of a QuotaIncrease with a single merge patch.
+// No request is sent if the QuotaIncrease is already up-to-date.
+func (r *QuotaController) patchQuotaIncrease(ctx context.Context, tgt *clusters.Cluster, current, desired *quotav1alpha1.QuotaIncrease) error {
+	if equality.Semantic.DeepEqual(current, desired) {
+		quotaIncreaseWritesTotal.WithLabelValues(quotaIncreaseWriteSkipped).Inc()
+		return nil
+	}
+	change := &AuditChange{Kind: "QuotaIncrease", Name: current.Name, Action: AuditActionUpdate, Details: quotaIncreaseChange(current, desired)}
+	return r.write(ctx, change, func() error {
+		if err := tgt.Client().Patch(ctx, desired, client.MergeFrom(current)); err != nil {
+			err = fmt.Errorf("error patching QuotaIncrease '%s': %w", client.ObjectKeyFromObject(current).String(), err)
+			if apierrors.IsInvalid(err) {
+				// retrying won't help until the QuotaIncrease is fixed
+				return NewPermanentError(err)
+			}
+			return err
+		}
+		quotaIncreaseWritesTotal.WithLabelValues(quotaIncreaseWritePatched).Inc()
+		return nil
+	})
+}
+
+// quotaIncreaseChange describes the differences between the current and the desired metadata of a QuotaIncrease.
+func quotaIncreaseChange(current, desired *quotav1alpha1.QuotaIncrease) string {
+	details := []string{}
+	if oldEffect, newEffect := current.Annotations[quotav1alpha1.EffectAnnotation], desired.Annotations[quotav1alpha1.EffectAnnotation]; oldEffect != newEffect {
+		details = append(details, fmt.Sprintf("effect '%s' -> '%s'", oldEffect, newEffect))
+	}
+	if current.Annotations[quotav1alpha1.EffectDetailsAnnotation] != desired.Annotations[quotav1alpha1.EffectDetailsAnnotation] {
+		// the details are too long to be reported
+		details = append(details, "effect details")
+	}
+	details = append(details, labelChanges(desired.Labels, current.Labels)...)
+	return strings.Join(details, ", ")
+}
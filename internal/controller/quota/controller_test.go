@@ -3,6 +3,7 @@ package quota_test
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -11,9 +12,13 @@ import (
 	gtypes "github.com/onsi/gomega/types"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/openmcp-project/controller-utils/pkg/clusters"
@@ -30,6 +35,7 @@ const (
 	platformCluster   = "platform"
 	onboardingCluster = "onboarding"
 	rec               = providerName
+	cfgRec            = "config"
 )
 
 func TestConfig(t *testing.T) {
@@ -71,21 +77,39 @@ func defaultTestSetup(mode quotav1alpha1.QuotaIncreaseOperatingMode, deleteIneff
 		WithInitObjectPath(onboardingCluster, filepath.Join(testDataPathSegments...), "onboarding").
 		WithFakeClient(platformCluster, quotainstall.InstallOperatorAPIsPlatform(runtime.NewScheme())).
 		WithFakeClient(onboardingCluster, quotainstall.InstallOperatorAPIsOnboarding(runtime.NewScheme())).
+		WithFakeClientBuilderCall(platformCluster, "WithStatusSubresource", &quotav1alpha1.QuotaServiceConfig{}).
+		WithFakeClientBuilderCall(onboardingCluster, "WithReturnManagedFields").
 		WithReconcilerConstructor(rec, func(c ...client.Client) reconcile.Reconciler {
 			return quotacontroller.NewQuotaController(clusters.NewTestClusterFromClient(platformCluster, c[0]), clusters.NewTestClusterFromClient(onboardingCluster, c[1]), providerName)
 		}, platformCluster, onboardingCluster).
 		Build()
 
+	registerConfigWatcher(env, env.Reconciler(rec).(*quotacontroller.QuotaController))
+	updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+		for i := range cfg.Spec.Quotas {
+			cfg.Spec.Quotas[i].Mode = mode
+			cfg.Spec.Quotas[i].DeleteIneffectiveQuotas = deleteIneffectiveQuotas
+		}
+	})
+
+	return env
+}
+
+// registerConfigWatcher registers the ConfigWatcher of the given QuotaController as reconciler in the environment.
+func registerConfigWatcher(env *testutils.ComplexEnvironment, qc *quotacontroller.QuotaController) {
+	env.Reconcilers[cfgRec] = qc.Configs
+}
+
+// updateConfig modifies the QuotaServiceConfig and lets the ConfigWatcher load the new generation.
+// The generation is increased manually, because the fake client does not do it.
+func updateConfig(env *testutils.ComplexEnvironment, modify func(cfg *quotav1alpha1.QuotaServiceConfig)) {
 	cfg := &quotav1alpha1.QuotaServiceConfig{}
 	cfg.SetName(providerName)
 	ExpectWithOffset(1, env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
-	for i := range cfg.Spec.Quotas {
-		cfg.Spec.Quotas[i].Mode = mode
-		cfg.Spec.Quotas[i].DeleteIneffectiveQuotas = deleteIneffectiveQuotas
-	}
+	modify(cfg)
+	cfg.Generation++
 	ExpectWithOffset(1, env.Client(platformCluster).Update(env.Ctx, cfg)).To(Succeed())
-
-	return env
+	env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
 }
 
 var _ = Describe("CO-1155 QuotaIncrease Controller", func() {
@@ -132,6 +156,499 @@ var _ = Describe("CO-1155 QuotaIncrease Controller", func() {
 			Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "all"))
 		})
 
+		It("should ignore quota definitions targeting MCP clusters", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-03")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "all"))
+			rql := &corev1.ResourceQuotaList{}
+			Expect(env.Client(onboardingCluster).List(env.Ctx, rql, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(rql.Items).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+					"Name": Equal("all"),
+				}),
+			})))
+		})
+
+		It("should create a LimitRange next to the ResourceQuota and delete it if the template is removed", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-04")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+
+			lr := &corev1.LimitRange{}
+			lr.SetName("all")
+			lr.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(lr), lr)).To(Succeed())
+			Expect(lr.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(lr.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+			Expect(lr.Labels).To(HaveKeyWithValue(quotav1alpha1.QuotaDefinitionLabel, "all"))
+			Expect(lr.OwnerReferences).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Kind": Equal("Namespace"),
+				"Name": Equal(ns.Name),
+			})))
+			Expect(lr.Spec.Limits).To(HaveLen(1))
+			// the LimitRange is not scaled, although the ResourceQuota was increased
+			Expect(lr.Spec.Limits[0].Default[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("500m")))
+
+			// remove LimitRange template from config
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				for _, qd := range cfg.Spec.Quotas {
+					qd.LimitRangeTemplate = nil
+				}
+			})
+
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(lr), lr)).To(MatchError(apierrors.IsNotFound, "IsNotFound"))
+		})
+
+		It("should scale the LimitRange along with the ResourceQuota, if configured", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-04")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-scaled")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			lr := &corev1.LimitRange{}
+			lr.SetName("scaled")
+			lr.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(lr), lr)).To(Succeed())
+			Expect(lr.Spec.Limits).To(HaveLen(1))
+			item := lr.Spec.Limits[0]
+			// 'limits.cpu' has been doubled
+			Expect(item.Max[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("4")))
+			Expect(item.Default[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("1")))
+			// 'requests.cpu' has been doubled
+			Expect(item.DefaultRequest[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("500m")))
+			// there is no 'limits.memory' quota
+			Expect(item.Default[corev1.ResourceMemory]).To(matchQuantity(resource.MustParse("512Mi")))
+			// 'memory' quota (which equals 'requests.memory') has been doubled
+			Expect(item.DefaultRequest[corev1.ResourceMemory]).To(matchQuantity(resource.MustParse("512Mi")))
+		})
+
+		It("should not scale the default requests of the LimitRange beyond the default limits and the maximum", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-04")
+
+			// only the requests are increased
+			qi := &quotav1alpha1.QuotaIncrease{}
+			qi.SetName("qi-scaled")
+			qi.SetNamespace("ns-scaled")
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(qi), qi)).To(Succeed())
+			qi.Spec.Hard = corev1.ResourceList{
+				"requests.cpu": resource.MustParse("6"),
+				"memory":       resource.MustParse("12Gi"),
+			}
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, qi)).To(Succeed())
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-scaled")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			lr := &corev1.LimitRange{}
+			lr.SetName("scaled")
+			lr.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(lr), lr)).To(Succeed())
+			Expect(lr.Spec.Limits).To(HaveLen(1))
+			item := lr.Spec.Limits[0]
+			// the limits are not scaled
+			Expect(item.Max[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("2")))
+			Expect(item.Default[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("500m")))
+			Expect(item.Default[corev1.ResourceMemory]).To(matchQuantity(resource.MustParse("512Mi")))
+			// the quadrupled default requests are capped at the default limits
+			Expect(item.DefaultRequest[corev1.ResourceCPU]).To(matchQuantity(resource.MustParse("500m")))
+			Expect(item.DefaultRequest[corev1.ResourceMemory]).To(matchQuantity(resource.MustParse("512Mi")))
+		})
+
+		It("should scale large quantities of the LimitRange exactly and round them to whole units", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-04")
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				qdef := cfg.Spec.GetQuotaDefinitionForName("scaled")
+				qdef.ResourceQuotaTemplate.Spec.Hard["requests.ephemeral-storage"] = resource.MustParse("2")
+				item := &qdef.LimitRangeTemplate.Spec.Limits[0]
+				// the default requests are not capped
+				delete(item.Default, corev1.ResourceMemory)
+				// the milli value of the scaled quantity exceeds the int64 range
+				item.DefaultRequest[corev1.ResourceMemory] = resource.MustParse("6Pi")
+				item.DefaultRequest[corev1.ResourceEphemeralStorage] = resource.MustParse("1001")
+			})
+			qi := &quotav1alpha1.QuotaIncrease{}
+			qi.SetName("qi-scaled")
+			qi.SetNamespace("ns-scaled")
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(qi), qi)).To(Succeed())
+			qi.Spec.Hard = corev1.ResourceList{
+				"memory":                     resource.MustParse("2Gi"),
+				"requests.ephemeral-storage": resource.MustParse("1"),
+			}
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, qi)).To(Succeed())
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-scaled")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			lr := &corev1.LimitRange{}
+			lr.SetName("scaled")
+			lr.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(lr), lr)).To(Succeed())
+			item := lr.Spec.Limits[0]
+			// both quotas have been increased by half
+			Expect(item.DefaultRequest[corev1.ResourceMemory]).To(matchQuantity(resource.MustParse("9Pi")))
+			// 1501.5 bytes are rounded down
+			Expect(item.DefaultRequest[corev1.ResourceEphemeralStorage]).To(matchQuantity(resource.MustParse("1501")))
+		})
+
+		It("should preserve metadata added by other tools and restore modified fields of the ResourceQuota", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			rq := &corev1.ResourceQuota{}
+			rq.SetName("limited")
+			rq.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.OwnerReferences).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Kind":       Equal("Namespace"),
+				"Name":       Equal(ns.Name),
+				"Controller": PointTo(BeTrue()),
+			})))
+			rq.Labels["other.tool/label"] = "foo"
+			rq.Annotations = map[string]string{"other.tool/annotation": "bar"}
+			rq.Spec.Hard[corev1.ResourceName("count/secrets")] = resource.MustParse("100")
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq, client.FieldOwner("other-tool"))).To(Succeed())
+
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			ns.Labels["other.tool/label"] = "foo"
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, ns, client.FieldOwner("other-tool"))).To(Succeed())
+
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.Labels).To(HaveKeyWithValue("other.tool/label", "foo"))
+			Expect(rq.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+			Expect(rq.Annotations).To(HaveKeyWithValue("other.tool/annotation", "bar"))
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(12)))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue("other.tool/label", "foo"))
+			Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "limited"))
+		})
+
+		It("should be triggered by manual changes of the ResourceQuota spec, but not by status updates", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+			qc := env.Reconciler(rec).(*quotacontroller.QuotaController)
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			w, err := env.Client(onboardingCluster).(client.WithWatch).Watch(env.Ctx, &corev1.ResourceQuotaList{}, client.InNamespace(ns.Name))
+			Expect(err).ToNot(HaveOccurred())
+			defer w.Stop()
+			// nextUpdate waits for the next watch event and returns it as update event of the given old object
+			nextUpdate := func(old client.Object) event.UpdateEvent {
+				var ev watch.Event
+				EventuallyWithOffset(1, w.ResultChan()).Should(Receive(&ev))
+				ExpectWithOffset(1, ev.Type).To(Equal(watch.Modified))
+				return event.UpdateEvent{ObjectOld: old, ObjectNew: ev.Object.(client.Object)}
+			}
+
+			rq := &corev1.ResourceQuota{}
+			rq.SetName("limited")
+			rq.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+
+			// status updates are ignored
+			old := rq.DeepCopy()
+			rq.Status.Used = corev1.ResourceList{"count/secrets": resource.MustParse("1")}
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq)).To(Succeed())
+			Expect(qc.OwnedObjectPredicate().Update(nextUpdate(old))).To(BeFalse())
+
+			// manual spec changes don't bump the generation, but trigger a reconcile
+			old = rq.DeepCopy()
+			rq.Spec.Hard[corev1.ResourceName("count/secrets")] = resource.MustParse("100")
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq, client.FieldOwner("other-tool"))).To(Succeed())
+			e := nextUpdate(old)
+			Expect(e.ObjectNew.GetGeneration()).To(Equal(old.GetGeneration()))
+			Expect(qc.OwnedObjectPredicate().Update(e)).To(BeTrue())
+
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(12)))
+
+			// label changes trigger a reconcile as well, but not if the object is managed by another controller instance
+			old = rq.DeepCopy()
+			rq.Labels[quotav1alpha1.ManagedByLabel] = "other"
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq)).To(Succeed())
+			e = nextUpdate(old)
+			Expect(qc.OwnedObjectPredicate().Update(e)).To(BeFalse())
+			e.ObjectNew.SetLabels(old.GetLabels())
+			e.ObjectNew.SetAnnotations(map[string]string{"other.tool/annotation": "bar"})
+			Expect(qc.OwnedObjectPredicate().Update(e)).To(BeTrue())
+		})
+
+		It("should report drift of the ResourceQuota and only correct it if the drift policy is not 'reportOnly'", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(openmcpctrlutil.EnsureAnnotation(env.Ctx, env.Client(onboardingCluster), ns, quotav1alpha1.DriftPolicyAnnotation, quotav1alpha1.DriftPolicyReportOnly, true)).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			rq := &corev1.ResourceQuota{}
+			rq.SetName("limited")
+			rq.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			rq.Spec.Hard[corev1.ResourceName("count/secrets")] = resource.MustParse("100")
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq, client.FieldOwner("other-tool"))).To(Succeed())
+
+			// drift is reported, but not corrected
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(100)))
+			Expect(rq.Annotations).To(HaveKeyWithValue(quotav1alpha1.DriftAnnotation, "spec.hard.count/secrets modified by other-tool (Update)"))
+			events := &corev1.EventList{}
+			Expect(env.Client(onboardingCluster).List(env.Ctx, events, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(events.Items).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Reason":  Equal("ResourceQuotaDrift"),
+				"Type":    Equal(corev1.EventTypeWarning),
+				"Message": ContainSubstring("other-tool"),
+			})))
+
+			// the same drift is not reported again
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).List(env.Ctx, events, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(events.Items).To(HaveLen(1))
+
+			// the uncorrected drift is reported in the config status
+			cfg := &quotav1alpha1.QuotaServiceConfig{}
+			cfg.SetName(providerName)
+			env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+			Expect(env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+			cond := meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionInSync)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(quotav1alpha1.ConfigReasonDriftNotCorrected))
+			Expect(cond.Message).To(ContainSubstring("namespace 'ns-normal' in cluster 'onboarding': spec.hard.count/secrets modified by other-tool (Update)"))
+
+			// other changes of the desired state are still applied
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				cfg.Spec.GetQuotaDefinitionForName("limited").ResourceQuotaTemplate.Spec.Hard["count/pods"] = resource.MustParse("7")
+			})
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/pods"), matchNumericQuantity(7)))
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(100)))
+			Expect(rq.Annotations).To(HaveKey(quotav1alpha1.DriftAnnotation))
+
+			// drift is corrected after removing the policy
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(openmcpctrlutil.EnsureAnnotation(env.Ctx, env.Client(onboardingCluster), ns, quotav1alpha1.DriftPolicyAnnotation, "", true, openmcpctrlutil.DELETE)).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(12)))
+			Expect(rq.Annotations).ToNot(HaveKey(quotav1alpha1.DriftAnnotation))
+			env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+			Expect(env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+			cond = meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionInSync)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should report apply conflicts and only force ownership if the drift policy is not 'reportOnly'", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			// conflictEvents returns the messages of all events which report apply conflicts
+			conflictEvents := func() []string {
+				evs := &corev1.EventList{}
+				ExpectWithOffset(1, env.Client(onboardingCluster).List(env.Ctx, evs)).To(Succeed())
+				res := []string{}
+				for _, ev := range evs.Items {
+					if ev.Reason == "ApplyConflict" {
+						ExpectWithOffset(1, ev.Type).To(Equal(corev1.EventTypeWarning))
+						res = append(res, ev.Message)
+					}
+				}
+				return res
+			}
+
+			// a GitOps tool takes over a label managed by the controller
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			ns.Labels[quotav1alpha1.BaseQuotaLabel] = "other"
+			ns.Annotations = map[string]string{quotav1alpha1.DriftPolicyAnnotation: quotav1alpha1.DriftPolicyReportOnly}
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, ns, client.FieldOwner("gitops"))).To(Succeed())
+
+			_, err := env.Reconciler(rec).Reconcile(env.Ctx, testutils.RequestFromObject(ns))
+			Expect(err).To(HaveOccurred())
+			Expect(quotacontroller.ErrorClassOf(err)).To(Equal(quotacontroller.ErrorClassPermanent))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "other"))
+			Expect(conflictEvents()).To(ConsistOf(And(ContainSubstring("Namespace 'ns-normal' is not updated"), ContainSubstring("gitops"), ContainSubstring(quotav1alpha1.BaseQuotaLabel))))
+
+			// with the default policy, the label is taken over
+			Expect(openmcpctrlutil.EnsureAnnotation(env.Ctx, env.Client(onboardingCluster), ns, quotav1alpha1.DriftPolicyAnnotation, "", true, openmcpctrlutil.DELETE)).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "limited"))
+			Expect(conflictEvents()).To(ContainElement(And(ContainSubstring("Taking over fields of Namespace 'ns-normal'"), ContainSubstring("gitops"))))
+		})
+
+		It("should stop reporting drift of namespaces which are managed by another controller instance", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(openmcpctrlutil.EnsureAnnotation(env.Ctx, env.Client(onboardingCluster), ns, quotav1alpha1.DriftPolicyAnnotation, quotav1alpha1.DriftPolicyReportOnly, true)).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			rq := &corev1.ResourceQuota{}
+			rq.SetName("limited")
+			rq.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			rq.Spec.Hard[corev1.ResourceName("count/secrets")] = resource.MustParse("100")
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, rq, client.FieldOwner("other-tool"))).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			// inSync reconciles the config and returns the status of its 'InSync' condition
+			inSync := func() metav1.ConditionStatus {
+				cfg := &quotav1alpha1.QuotaServiceConfig{}
+				cfg.SetName(providerName)
+				env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+				ExpectWithOffset(1, env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+				cond := meta.FindStatusCondition(cfg.Status.Conditions, quotav1alpha1.ConfigConditionInSync)
+				ExpectWithOffset(1, cond).ToNot(BeNil())
+				return cond.Status
+			}
+			Expect(inSync()).To(Equal(metav1.ConditionFalse))
+
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			Expect(openmcpctrlutil.EnsureLabel(env.Ctx, env.Client(onboardingCluster), ns, quotav1alpha1.ManagedByLabel, "foreign", true, openmcpctrlutil.OVERWRITE)).To(Succeed())
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(inSync()).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should reject QuotaIncreases which exceed the configured limits, in order of their creation", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			rq := &corev1.ResourceQuota{}
+			rq.SetName("limited")
+			rq.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+			// 3 (base) + 4 (beta) + 5 (alpha)
+			Expect(rq.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(12)))
+			Expect(rq.Spec.Hard).ToNot(HaveKey(corev1.ResourceName("count/configmaps")))
+
+			qis := &quotav1alpha1.QuotaIncreaseList{}
+			Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(qis.Items).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{
+					"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+						"Name":        Equal("qi-normal-alpha"),
+						"Annotations": HaveKeyWithValue(quotav1alpha1.EffectAnnotation, "count/secrets: 5"),
+					}),
+				}),
+				MatchFields(IgnoreExtras, Fields{
+					"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+						"Name":        Equal("qi-normal-beta"),
+						"Annotations": HaveKeyWithValue(quotav1alpha1.EffectAnnotation, "count/secrets: 4"),
+					}),
+				}),
+				MatchFields(IgnoreExtras, Fields{
+					"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+						"Name":        Equal("qi-normal-gamma"),
+						"Annotations": HaveKeyWithValue(quotav1alpha1.EffectAnnotation, HavePrefix(quotav1alpha1.RejectedQuotaIncreaseEffectPrefix+" maximum increase")),
+					}),
+				}),
+				MatchFields(IgnoreExtras, Fields{
+					"ObjectMeta": MatchFields(IgnoreExtras, Fields{
+						"Name":        Equal("qi-normal-delta"),
+						"Annotations": HaveKeyWithValue(quotav1alpha1.EffectAnnotation, HavePrefix(quotav1alpha1.RejectedQuotaIncreaseEffectPrefix+" maximum number")),
+					}),
+				}),
+			))
+		})
+
+		It("should delete rejected QuotaIncreases if deleteRejected is true", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-05")
+
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				cfg.Spec.Quotas[0].QuotaIncreaseLimits.DeleteRejected = true
+			})
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			qis := &quotav1alpha1.QuotaIncreaseList{}
+			Expect(env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+			Expect(qis.Items).To(withPointerizedSlice[quotav1alpha1.QuotaIncrease](ConsistOf(
+				haveName("qi-normal-alpha"),
+				haveName("qi-normal-beta"),
+			)))
+		})
+
+		It("should update the metadata of a QuotaIncrease with a single write and skip up-to-date QuotaIncreases", func() {
+			env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-project")
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+			resourceVersions := func() map[string]string {
+				qis := &quotav1alpha1.QuotaIncreaseList{}
+				ExpectWithOffset(1, env.Client(onboardingCluster).List(env.Ctx, qis, client.InNamespace(ns.Name))).To(Succeed())
+				res := map[string]string{}
+				for _, qi := range qis.Items {
+					res[qi.Name] = qi.ResourceVersion
+				}
+				return res
+			}
+			before := resourceVersions()
+			Expect(before).ToNot(BeEmpty())
+
+			// up-to-date QuotaIncreases must not be written
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(resourceVersions()).To(Equal(before))
+
+			// outdated annotation and label are restored with a single write
+			qi := &quotav1alpha1.QuotaIncrease{}
+			qi.SetName("qi-project-max")
+			qi.SetNamespace(ns.Name)
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(qi), qi)).To(Succeed())
+			effect := qi.Annotations[quotav1alpha1.EffectAnnotation]
+			qi.Annotations[quotav1alpha1.EffectAnnotation] = "outdated"
+			qi.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel] = string(quotav1alpha1.MAXIMUM)
+			Expect(env.Client(onboardingCluster).Update(env.Ctx, qi)).To(Succeed())
+			before = resourceVersions()
+			oldRV, err := strconv.Atoi(qi.ResourceVersion)
+			Expect(err).ToNot(HaveOccurred())
+
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(qi), qi)).To(Succeed())
+			Expect(qi.Annotations).To(HaveKeyWithValue(quotav1alpha1.EffectAnnotation, effect))
+			Expect(qi.Labels).To(HaveKeyWithValue(quotav1alpha1.QuotaIncreaseOperationModeLabel, string(quotav1alpha1.CUMULATIVE)))
+			Expect(qi.ResourceVersion).To(Equal(strconv.Itoa(oldRV + 1)))
+			after := resourceVersions()
+			delete(before, qi.Name)
+			delete(after, qi.Name)
+			Expect(after).To(Equal(before))
+		})
+
 	})
 
 	Context(fmt.Sprintf("Operating Mode: %s", quotav1alpha1.CUMULATIVE), func() {
@@ -0,0 +1,250 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	openapiconst "github.com/openmcp-project/openmcp-operator/api/constants"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// QuotaIncreaseChangeType describes how a QuotaIncrease is affected by a config change.
+type QuotaIncreaseChangeType string
+
+const (
+	// QuotaIncreaseChangeIneffective means that the QuotaIncrease currently has an effect, but would not have one anymore.
+	QuotaIncreaseChangeIneffective QuotaIncreaseChangeType = "ineffective"
+	// QuotaIncreaseChangeDeleted means that the QuotaIncrease would be deleted.
+	QuotaIncreaseChangeDeleted QuotaIncreaseChangeType = "deleted"
+	// QuotaIncreaseChangeRejected means that the QuotaIncrease would be rejected due to the limits of the quota definition.
+	QuotaIncreaseChangeRejected QuotaIncreaseChangeType = "rejected"
+	// QuotaIncreaseChangeEffect means that the effect of the QuotaIncrease would change.
+	QuotaIncreaseChangeEffect QuotaIncreaseChangeType = "effectChanged"
+)
+
+// NamespaceDiff describes how a namespace would be affected by a config change.
+type NamespaceDiff struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// OldQuotaDefinition is the name of the quota definition which is currently applied to the namespace.
+	// Empty if the namespace is currently not managed.
+	OldQuotaDefinition string `json:"oldQuotaDefinition,omitempty"`
+	// NewQuotaDefinition is the name of the quota definition which would be applied to the namespace.
+	// Empty if no quota definition of the new config matches the namespace. Note that the existing ResourceQuota is not removed in this case.
+	NewQuotaDefinition string `json:"newQuotaDefinition,omitempty"`
+	// HardLimits contains the entries of the ResourceQuota's hard limits which would change, sorted by resource name.
+	HardLimits []HardLimitChange `json:"hardLimits,omitempty"`
+	// QuotaIncreases contains the QuotaIncreases which would be affected, sorted by name.
+	QuotaIncreases []QuotaIncreaseChange `json:"quotaIncreases,omitempty"`
+	// BelowUsage contains the resources whose new hard limit would be below the current usage, sorted by resource name.
+	BelowUsage []UsageViolation `json:"belowUsage,omitempty"`
+}
+
+// DefinitionChanged returns true if a different quota definition would be applied to the namespace.
+func (d *NamespaceDiff) DefinitionChanged() bool {
+	return d.OldQuotaDefinition != d.NewQuotaDefinition
+}
+
+// IsEmpty returns true if the namespace would not be affected by the config change.
+func (d *NamespaceDiff) IsEmpty() bool {
+	return !d.DefinitionChanged() && len(d.HardLimits) == 0 && len(d.QuotaIncreases) == 0 && len(d.BelowUsage) == 0
+}
+
+// HardLimitChange describes the change of a single hard limit of a ResourceQuota.
+type HardLimitChange struct {
+	// Resource is the name of the resource.
+	Resource corev1.ResourceName `json:"resource"`
+	// Old is the current hard limit. Nil if the resource is currently not limited.
+	Old *resource.Quantity `json:"old,omitempty"`
+	// New is the new hard limit. Nil if the resource would not be limited anymore.
+	New *resource.Quantity `json:"new,omitempty"`
+}
+
+// String returns a human-readable description of the change, e.g. 'cpu: 1 -> 2'.
+func (c HardLimitChange) String() string {
+	quantity := func(q *resource.Quantity) string {
+		if q == nil {
+			return "<none>"
+		}
+		return q.String()
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Resource, quantity(c.Old), quantity(c.New))
+}
+
+// QuotaIncreaseChange describes how a single QuotaIncrease would be affected.
+type QuotaIncreaseChange struct {
+	// Name is the name of the QuotaIncrease.
+	Name string `json:"name"`
+	// Change is the type of the change.
+	Change QuotaIncreaseChangeType `json:"change"`
+	// OldEffect is the current value of the QuotaIncrease's effect annotation.
+	OldEffect string `json:"oldEffect,omitempty"`
+	// NewEffect is the value the effect annotation would have. Empty if the QuotaIncrease would be deleted.
+	NewEffect string `json:"newEffect,omitempty"`
+}
+
+// UsageViolation describes a resource whose new hard limit would be below its current usage.
+type UsageViolation struct {
+	// Resource is the name of the resource.
+	Resource corev1.ResourceName `json:"resource"`
+	// Hard is the new hard limit.
+	Hard resource.Quantity `json:"hard"`
+	// Used is the current usage, as reported in the status of the current ResourceQuota.
+	Used resource.Quantity `json:"used"`
+}
+
+// Diff computes how the given live state of the onboarding cluster would be affected if the given config was applied.
+// The namespaces, QuotaIncreases and ResourceQuotas are expected to be the live objects from the onboarding cluster.
+// Namespaces which are ignored by the QuotaController or managed by another instance of it are skipped, as are namespaces which would not be affected.
+// The result is sorted by namespace name. The context is expected to contain a logger.
+func Diff(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig, providerName string, namespaces []*corev1.Namespace, qis []*quotav1alpha1.QuotaIncrease, rqs []*corev1.ResourceQuota) ([]NamespaceDiff, error) {
+	managed := reconciledNamespaces(namespaces, providerName)
+	nsByName := map[string]*corev1.Namespace{}
+	for _, ns := range managed {
+		nsByName[ns.Name] = ns
+	}
+
+	sims, err := Simulate(ctx, cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, managed, qis)
+	if err != nil {
+		return nil, err
+	}
+
+	liveEffects := map[string]map[string]string{}
+	for _, qi := range qis {
+		if _, ok := liveEffects[qi.Namespace]; !ok {
+			liveEffects[qi.Namespace] = map[string]string{}
+		}
+		liveEffects[qi.Namespace][qi.Name] = qi.Annotations[quotav1alpha1.EffectAnnotation]
+	}
+
+	res := []NamespaceDiff{}
+	for _, sim := range sims {
+		ns := nsByName[sim.Namespace]
+		d := NamespaceDiff{
+			Namespace:          sim.Namespace,
+			NewQuotaDefinition: sim.QuotaDefinition,
+		}
+		if managedBy, ok := ctrlutils.GetLabel(ns, quotav1alpha1.ManagedByLabel); ok && managedBy == providerName {
+			d.OldQuotaDefinition = ns.Labels[quotav1alpha1.BaseQuotaLabel]
+		}
+		if sim.QuotaDefinition == "" {
+			// the namespace is not touched by the controller anymore
+			if !d.IsEmpty() {
+				res = append(res, d)
+			}
+			continue
+		}
+
+		live := findManagedResourceQuota(rqs, providerName, sim.Namespace, d.OldQuotaDefinition)
+		var oldHard, used corev1.ResourceList
+		if live != nil {
+			oldHard = live.Spec.Hard
+			used = live.Status.Used
+		}
+		newHard := sim.ResourceQuota.Spec.Hard
+		d.HardLimits = hardLimitChanges(oldHard, newHard)
+		for _, rn := range sets.List(sets.KeySet(newHard)) {
+			newQ := newHard[rn]
+			if usedQ, ok := used[rn]; ok && newQ.Cmp(usedQ) < 0 {
+				d.BelowUsage = append(d.BelowUsage, UsageViolation{Resource: rn, Hard: newQ, Used: usedQ})
+			}
+		}
+
+		for _, qiSim := range sim.QuotaIncreases {
+			oldEffect := liveEffects[sim.Namespace][qiSim.Name]
+			change := QuotaIncreaseChange{
+				Name:      qiSim.Name,
+				OldEffect: oldEffect,
+				NewEffect: qiSim.EffectAnnotation,
+			}
+			switch {
+			case qiSim.Deleted:
+				change.Change = QuotaIncreaseChangeDeleted
+			case qiSim.Rejected != "" && !strings.HasPrefix(oldEffect, quotav1alpha1.RejectedQuotaIncreaseEffectPrefix):
+				change.Change = QuotaIncreaseChangeRejected
+			case len(qiSim.Effect) == 0 && qiSim.Rejected == "" && hasEffect(oldEffect):
+				change.Change = QuotaIncreaseChangeIneffective
+			case oldEffect != qiSim.EffectAnnotation:
+				change.Change = QuotaIncreaseChangeEffect
+			default:
+				continue
+			}
+			d.QuotaIncreases = append(d.QuotaIncreases, change)
+		}
+
+		if !d.IsEmpty() {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+// hardLimitChanges returns the entries which differ between the old and the new hard limits, sorted by resource name.
+func hardLimitChanges(oldHard, newHard corev1.ResourceList) []HardLimitChange {
+	var res []HardLimitChange
+	for _, rn := range sets.List(sets.KeySet(oldHard).Union(sets.KeySet(newHard))) {
+		oldQ, oldOk := oldHard[rn]
+		newQ, newOk := newHard[rn]
+		if oldOk && newOk && oldQ.Cmp(newQ) == 0 {
+			continue
+		}
+		change := HardLimitChange{Resource: rn}
+		if oldOk {
+			change.Old = &oldQ
+		}
+		if newOk {
+			change.New = &newQ
+		}
+		res = append(res, change)
+	}
+	return res
+}
+
+// findManagedResourceQuota returns the ResourceQuota in the given namespace which has been generated by the controller with the given provider name for the given quota definition.
+// If there is no such ResourceQuota, any ResourceQuota generated by the controller in the namespace is returned. Returns nil if none is found.
+func findManagedResourceQuota(rqs []*corev1.ResourceQuota, providerName, namespace, qdefName string) *corev1.ResourceQuota {
+	var fallback *corev1.ResourceQuota
+	for _, rq := range rqs {
+		if rq.Namespace != namespace || !ctrlutils.HasLabelWithValue(rq, quotav1alpha1.ManagedByLabel, providerName) {
+			continue
+		}
+		if ctrlutils.HasLabelWithValue(rq, quotav1alpha1.QuotaDefinitionLabel, qdefName) {
+			return rq
+		}
+		if fallback == nil {
+			fallback = rq
+		}
+	}
+	return fallback
+}
+
+// hasEffect returns true if the given value of an effect annotation indicates that the QuotaIncrease has an effect.
+func hasEffect(effectAnnotation string) bool {
+	if strings.HasPrefix(effectAnnotation, quotav1alpha1.RejectedQuotaIncreaseEffectPrefix) {
+		return false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(effectAnnotation, quotav1alpha1.ActiveSingularQuotaIncreaseEffectPrefix)) != ""
+}
+
+// reconciledNamespaces returns the namespaces which are reconciled by the QuotaController with the given provider name,
+// which are all namespaces that are neither ignored nor managed by another instance of the QuotaController.
+func reconciledNamespaces(namespaces []*corev1.Namespace, providerName string) []*corev1.Namespace {
+	res := make([]*corev1.Namespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if ctrlutils.HasAnnotationWithValue(ns, openapiconst.OperationAnnotation, openapiconst.OperationAnnotationValueIgnore) || ctrlutils.HasAnnotationWithValue(ns, quotav1alpha1.QuotaOperationLabel, openapiconst.OperationAnnotationValueIgnore) {
+			continue
+		}
+		if managedBy, ok := ctrlutils.GetLabel(ns, quotav1alpha1.ManagedByLabel); ok && managedBy != providerName {
+			continue
+		}
+		res = append(res, ns)
+	}
+	return res
+}
@@ -0,0 +1,116 @@
+package quota_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Diff", func() {
+
+	It("should report hard limit changes, affected QuotaIncreases and resources below usage", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-05")
+
+		// live state as produced by the current config
+		ns := namespaces[0]
+		ns.Labels = map[string]string{
+			quotav1alpha1.ManagedByLabel: providerName,
+			quotav1alpha1.BaseQuotaLabel: "limited",
+		}
+		for _, qi := range qis {
+			if qi.Name == "qi-normal-alpha" {
+				qi.Annotations = map[string]string{quotav1alpha1.EffectAnnotation: "count/secrets: 5"}
+			}
+		}
+		rq := &corev1.ResourceQuota{}
+		rq.SetName("limited")
+		rq.SetNamespace(ns.Name)
+		rq.SetLabels(map[string]string{
+			quotav1alpha1.ManagedByLabel:       providerName,
+			quotav1alpha1.QuotaDefinitionLabel: "limited",
+		})
+		rq.Spec.Hard = corev1.ResourceList{"count/secrets": resource.MustParse("12")}
+		rq.Status.Used = corev1.ResourceList{"count/secrets": resource.MustParse("11")}
+
+		// candidate config: only one QuotaIncrease is allowed and rejected ones are deleted
+		maxCount := int32(1)
+		cfg.Spec.Quotas[0].QuotaIncreaseLimits.MaxCount = &maxCount
+		cfg.Spec.Quotas[0].QuotaIncreaseLimits.DeleteRejected = true
+
+		diffs, err := quotacontroller.Diff(logging.NewContextWithDiscard(context.Background()), cfg, providerName, namespaces, qis, []*corev1.ResourceQuota{rq})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveLen(1))
+		d := diffs[0]
+		Expect(d.Namespace).To(Equal(ns.Name))
+		Expect(d.DefinitionChanged()).To(BeFalse())
+		// 3 (base) + 4 (beta, oldest QuotaIncrease)
+		Expect(d.HardLimits).To(HaveExactElements(MatchAllFields(Fields{
+			"Resource": Equal(corev1.ResourceName("count/secrets")),
+			"Old":      PointTo(matchNumericQuantity(12)),
+			"New":      PointTo(matchNumericQuantity(7)),
+		})))
+		Expect(d.BelowUsage).To(HaveExactElements(MatchAllFields(Fields{
+			"Resource": Equal(corev1.ResourceName("count/secrets")),
+			"Hard":     matchNumericQuantity(7),
+			"Used":     matchNumericQuantity(11),
+		})))
+		Expect(d.QuotaIncreases).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Name":      Equal("qi-normal-alpha"),
+				"Change":    Equal(quotacontroller.QuotaIncreaseChangeDeleted),
+				"OldEffect": Equal("count/secrets: 5"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":      Equal("qi-normal-beta"),
+				"Change":    Equal(quotacontroller.QuotaIncreaseChangeEffect),
+				"NewEffect": Equal("count/secrets: 4"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":   Equal("qi-normal-delta"),
+				"Change": Equal(quotacontroller.QuotaIncreaseChangeDeleted),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":   Equal("qi-normal-gamma"),
+				"Change": Equal(quotacontroller.QuotaIncreaseChangeDeleted),
+			}),
+		))
+	})
+
+	It("should report namespaces which change their quota definition and skip unaffected or foreign namespaces", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-04")
+
+		for _, ns := range namespaces {
+			if ns.Labels == nil {
+				ns.Labels = map[string]string{}
+			}
+			switch ns.Name {
+			case "ns-normal":
+				// managed by another instance
+				ns.Labels[quotav1alpha1.ManagedByLabel] = "foreign"
+			case "ns-scaled":
+				// currently uses another definition
+				ns.Labels[quotav1alpha1.ManagedByLabel] = providerName
+				ns.Labels[quotav1alpha1.BaseQuotaLabel] = "all"
+			}
+		}
+
+		diffs, err := quotacontroller.Diff(logging.NewContextWithDiscard(context.Background()), cfg, providerName, namespaces, qis, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveExactElements(MatchFields(IgnoreExtras, Fields{
+			"Namespace":          Equal("ns-scaled"),
+			"OldQuotaDefinition": Equal("all"),
+			"NewQuotaDefinition": Equal("scaled"),
+			"HardLimits":         Not(BeEmpty()),
+		})))
+	})
+
+})
@@ -0,0 +1,143 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// resourceQuotaDrift describes the deviation of a live ResourceQuota from its desired state.
+type resourceQuotaDrift struct {
+	// Fields are the paths of the fields which deviate from the desired state, e.g. 'spec.hard.count/secrets'.
+	Fields []string
+	// Managers are the field managers (other than this controller) which own any of the deviating fields.
+	Managers []string
+}
+
+// String returns a human-readable description of the drift.
+// The description of nil is empty.
+func (d *resourceQuotaDrift) String() string {
+	if d == nil {
+		return ""
+	}
+	managers := "unknown field managers"
+	if len(d.Managers) > 0 {
+		managers = strings.Join(d.Managers, ", ")
+	}
+	return fmt.Sprintf("%s modified by %s", strings.Join(d.Fields, ", "), managers)
+}
+
+// withoutDriftedFields returns a copy of the desired ResourceQuota without the fields which deviate according to the given drift.
+// The copy is applied for namespaces with the 'reportOnly' drift policy: omitted fields are left as they are, because this controller does not own them anymore.
+func withoutDriftedFields(desired *corev1.ResourceQuota, drift *resourceQuotaDrift) *corev1.ResourceQuota {
+	res := desired.DeepCopy()
+	for _, field := range drift.Fields {
+		switch {
+		case strings.HasPrefix(field, "spec.hard."):
+			delete(res.Spec.Hard, corev1.ResourceName(strings.TrimPrefix(field, "spec.hard.")))
+		case field == "spec.scopes":
+			res.Spec.Scopes = nil
+		case field == "spec.scopeSelector":
+			res.Spec.ScopeSelector = nil
+		}
+	}
+	return res
+}
+
+// forgetDrift removes the recorded drift of a namespace which is not reconciled anymore, e.g. because it has been deleted, no quota definition matches it anymore
+// or it is managed by another instance of this platform service.
+func (r *QuotaController) forgetDrift(tgt *clusters.Cluster, namespace string) {
+	r.Configs.RecordDrift(tgt.ID(), namespace, "")
+}
+
+// driftPolicy returns the drift policy for the given namespace.
+func driftPolicy(namespace *corev1.Namespace) string {
+	if ctrlutils.HasAnnotationWithValue(namespace, quotav1alpha1.DriftPolicyAnnotation, quotav1alpha1.DriftPolicyReportOnly) {
+		return quotav1alpha1.DriftPolicyReportOnly
+	}
+	return quotav1alpha1.DriftPolicyCorrect
+}
+
+// computeDrift compares the spec of the live ResourceQuota with the desired one and returns the drift, or nil if there is none.
+// Only fields which are set by this controller are compared, entries of the hard quotas which have been added by other field managers are ignored.
+// Deviating fields which are still owned by this controller's field manager are not considered drift, because their value is the one that was last applied by this controller
+// and the deviation is caused by a change of the desired state (e.g. a new QuotaIncrease).
+// The managedFields of the live ResourceQuota are used to determine which other field managers own the deviating fields.
+func computeDrift(desired, live *corev1.ResourceQuota, ownFieldManager string) *resourceQuotaDrift {
+	candidates := [][]string{}
+	for _, res := range sets.List(sets.KeySet(desired.Spec.Hard)) {
+		liveQ, ok := live.Spec.Hard[res]
+		if !ok {
+			// missing entries cannot be distinguished from entries that have been added to the desired state, they are restored, but not reported
+			continue
+		}
+		if desiredQ := desired.Spec.Hard[res]; liveQ.Cmp(desiredQ) != 0 {
+			candidates = append(candidates, []string{"spec", "hard", string(res)})
+		}
+	}
+	if !equality.Semantic.DeepEqual(desired.Spec.Scopes, live.Spec.Scopes) {
+		candidates = append(candidates, []string{"spec", "scopes"})
+	}
+	if !equality.Semantic.DeepEqual(desired.Spec.ScopeSelector, live.Spec.ScopeSelector) {
+		candidates = append(candidates, []string{"spec", "scopeSelector"})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// parse managed fields
+	owned := map[string]map[string]any{}
+	operations := map[string]string{}
+	for _, mf := range live.ManagedFields {
+		if mf.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]any{}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		owned[mf.Manager] = fields
+		operations[mf.Manager] = string(mf.Operation)
+	}
+
+	drift := &resourceQuotaDrift{}
+	managers := sets.New[string]()
+	for _, path := range candidates {
+		if ownFields, ok := owned[ownFieldManager]; ok && ownsField(ownFields, path) {
+			continue
+		}
+		drift.Fields = append(drift.Fields, strings.Join(path, "."))
+		for manager, fields := range owned {
+			if manager != ownFieldManager && ownsField(fields, path) {
+				managers.Insert(fmt.Sprintf("%s (%s)", manager, operations[manager]))
+			}
+		}
+	}
+	if len(drift.Fields) == 0 {
+		return nil
+	}
+	drift.Managers = sets.List(managers)
+	return drift
+}
+
+// ownsField checks whether the given managedFields entry (in FieldsV1 format) contains the given field path.
+func ownsField(fields map[string]any, path []string) bool {
+	cur := fields
+	for _, segment := range path {
+		next, ok := cur["f:"+segment].(map[string]any)
+		if !ok {
+			return false
+		}
+		cur = next
+	}
+	return true
+}
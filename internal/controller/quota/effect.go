@@ -0,0 +1,98 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// EffectReason describes why a requested resource of a QuotaIncrease takes effect or not.
+type EffectReason string
+
+const (
+	// EffectReasonApplied means that the requested quantity contributes to the hard limit of the ResourceQuota.
+	EffectReasonApplied EffectReason = "applied"
+	// EffectReasonOvershadowedByBase means that the requested quantity is not higher than the value from the template of the quota definition.
+	EffectReasonOvershadowedByBase EffectReason = "overshadowedByBase"
+	// EffectReasonOvershadowedByQuotaIncrease means that another QuotaIncrease requests a higher quantity, which is applied instead.
+	EffectReasonOvershadowedByQuotaIncrease EffectReason = "overshadowedByQuotaIncrease"
+	// EffectReasonInactive means that the QuotaIncrease is not referenced by the namespace in singular mode.
+	EffectReasonInactive EffectReason = "inactive"
+	// EffectReasonRejected means that the QuotaIncrease has been rejected due to the limits of the quota definition.
+	EffectReasonRejected EffectReason = "rejected"
+)
+
+// QuotaIncreaseEffect describes the effect of a QuotaIncrease for each requested resource.
+// It is stored as JSON in the effect details annotation of the QuotaIncrease.
+type QuotaIncreaseEffect struct {
+	// Rejected contains the reason why the QuotaIncrease has been rejected due to the limits of the quota definition.
+	// Empty if the QuotaIncrease has not been rejected.
+	Rejected string `json:"rejected,omitempty"`
+	// Resources contains the effect of each requested resource.
+	Resources map[corev1.ResourceName]ResourceEffect `json:"resources"`
+}
+
+// ResourceEffect describes the effect of a single requested resource of a QuotaIncrease.
+type ResourceEffect struct {
+	// Requested is the quantity requested by the QuotaIncrease.
+	Requested resource.Quantity `json:"requested"`
+	// Applied is the quantity which contributes to the hard limit of the ResourceQuota. Nil if the requested quantity has no effect.
+	Applied *resource.Quantity `json:"applied,omitempty"`
+	// Reason describes why the requested quantity takes effect or not.
+	Reason EffectReason `json:"reason"`
+	// OvershadowedBy is the name of the QuotaIncrease which is applied instead, if the reason is EffectReasonOvershadowedByQuotaIncrease.
+	OvershadowedBy string `json:"overshadowedBy,omitempty"`
+}
+
+// quotaIncreaseEffect computes the effect of each requested resource of the given QuotaIncrease, based on the effects of all QuotaIncreases in the namespace and the rejections.
+// The base values are not part of the result, so that config changes which don't change the effect don't require an update of the QuotaIncrease.
+func quotaIncreaseEffect(qdef *quotav1alpha1.QuotaDefinition, namespace *corev1.Namespace, qi *quotav1alpha1.QuotaIncrease, effects map[string]corev1.ResourceList, rejections map[string]string) *QuotaIncreaseEffect {
+	singularQIName, _ := ctrlutils.GetLabel(namespace, quotav1alpha1.SingularQuotaIncreaseLabel)
+	res := &QuotaIncreaseEffect{
+		Rejected:  rejections[qi.Name],
+		Resources: make(map[corev1.ResourceName]ResourceEffect, len(qi.Spec.Hard)),
+	}
+	for rn, requested := range qi.Spec.Hard {
+		re := ResourceEffect{Requested: requested}
+		applied, isApplied := effects[qi.Name][rn]
+		switch {
+		case res.Rejected != "":
+			re.Reason = EffectReasonRejected
+		case isApplied:
+			re.Applied = &applied
+			re.Reason = EffectReasonApplied
+		case qdef.Mode == quotav1alpha1.SINGULAR && qi.Name != singularQIName:
+			re.Reason = EffectReasonInactive
+		default:
+			// in maximum mode, the resource is either overshadowed by the QuotaIncrease which is applied for it or by the base value
+			re.Reason = EffectReasonOvershadowedByBase
+			if qdef.Mode == quotav1alpha1.MAXIMUM {
+				for otherName, otherEffect := range effects {
+					if _, ok := otherEffect[rn]; ok && otherName != qi.Name {
+						re.Reason = EffectReasonOvershadowedByQuotaIncrease
+						re.OvershadowedBy = otherName
+					}
+				}
+			}
+		}
+		res.Resources[rn] = re
+	}
+	return res
+}
+
+// String returns the JSON representation of the effect, which is used as value of the effect details annotation.
+// The keys are sorted, so the value only changes if the effect changes.
+func (e *QuotaIncreaseEffect) String() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		// cannot happen, all fields are marshallable
+		return fmt.Sprintf("error marshalling effect: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,145 @@
+package quota_test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("QuotaIncrease Effect Details", func() {
+
+	ctx := logging.NewContextWithDiscard(context.Background())
+
+	// effectDetails simulates the given input and returns the effect details of the QuotaIncreases in ns-project, keyed by name.
+	effectDetails := func(cfg *quotav1alpha1.QuotaServiceConfig, namespaces []*corev1.Namespace, qis []*quotav1alpha1.QuotaIncrease) map[string]*quotacontroller.QuotaIncreaseEffect {
+		sims, err := quotacontroller.Simulate(ctx, cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, namespaces, qis)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		res := map[string]*quotacontroller.QuotaIncreaseEffect{}
+		for _, sim := range sims {
+			if sim.Namespace != "ns-project" {
+				continue
+			}
+			for _, qiSim := range sim.QuotaIncreases {
+				res[qiSim.Name] = qiSim.EffectDetails
+			}
+		}
+		return res
+	}
+
+	It("should report all requested resources as applied in cumulative mode", func() {
+		details := effectDetails(loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-01"))
+		Expect(details).To(HaveKey("qi-project-sa"))
+		sa := details["qi-project-sa"]
+		Expect(sa.Rejected).To(BeEmpty())
+		Expect(sa.Resources).To(HaveLen(2))
+		for _, re := range sa.Resources {
+			Expect(re.Reason).To(Equal(quotacontroller.EffectReasonApplied))
+			Expect(re.Applied).ToNot(BeNil())
+			Expect(*re.Applied).To(matchQuantity(re.Requested))
+		}
+		Expect(details["qi-project-empty"].Resources).To(BeEmpty())
+	})
+
+	It("should report which QuotaIncrease overshadows a requested resource in maximum mode", func() {
+		details := effectDetails(loadSimulationInput(quotav1alpha1.MAXIMUM, "testdata", "test-01"))
+
+		secrets := details["qi-project-max"].Resources["count/secrets"]
+		Expect(secrets.Reason).To(Equal(quotacontroller.EffectReasonApplied))
+		Expect(*secrets.Applied).To(matchNumericQuantity(30))
+
+		secrets = details["qi-project-med"].Resources["count/secrets"]
+		Expect(secrets.Reason).To(Equal(quotacontroller.EffectReasonOvershadowedByQuotaIncrease))
+		Expect(secrets.OvershadowedBy).To(Equal("qi-project-max"))
+		Expect(secrets.Requested).To(matchNumericQuantity(10))
+		Expect(secrets.Applied).To(BeNil())
+
+		// the QuotaIncrease is still effective for other resources
+		sa := details["qi-project-sa"]
+		Expect(sa.Resources["count/secrets"].Reason).To(Equal(quotacontroller.EffectReasonOvershadowedByQuotaIncrease))
+		Expect(sa.Resources["count/serviceaccounts"].Reason).To(Equal(quotacontroller.EffectReasonApplied))
+	})
+
+	It("should distinguish between the active and inactive QuotaIncreases in singular mode", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.SINGULAR, "testdata", "test-01")
+		for _, ns := range namespaces {
+			if ns.Name == "ns-project" {
+				if ns.Labels == nil {
+					ns.Labels = map[string]string{}
+				}
+				ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = "qi-project-med"
+			}
+		}
+		details := effectDetails(cfg, namespaces, qis)
+
+		Expect(details["qi-project-med"].Resources["count/secrets"].Reason).To(Equal(quotacontroller.EffectReasonApplied))
+		for _, re := range details["qi-project-max"].Resources {
+			Expect(re.Reason).To(Equal(quotacontroller.EffectReasonInactive))
+		}
+	})
+
+	It("should report requested resources which are not higher than the base value in singular mode", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.SINGULAR, "testdata", "test-01")
+		cfg.Spec.GetQuotaDefinitionForName("project").ResourceQuotaTemplate.Spec.Hard["count/secrets"] = resource.MustParse("100")
+		for _, ns := range namespaces {
+			if ns.Name == "ns-project" {
+				if ns.Labels == nil {
+					ns.Labels = map[string]string{}
+				}
+				ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = "qi-project-sa"
+			}
+		}
+		details := effectDetails(cfg, namespaces, qis)
+
+		sa := details["qi-project-sa"]
+		Expect(sa.Resources["count/secrets"].Reason).To(Equal(quotacontroller.EffectReasonOvershadowedByBase))
+		Expect(sa.Resources["count/serviceaccounts"].Reason).To(Equal(quotacontroller.EffectReasonApplied))
+	})
+
+	It("should report all requested resources of rejected QuotaIncreases as rejected", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-01")
+		cfg.Spec.GetQuotaDefinitionForName("project").QuotaIncreaseLimits = &quotav1alpha1.QuotaIncreaseLimits{
+			MaxCount: ptr.To[int32](1),
+		}
+		details := effectDetails(cfg, namespaces, qis)
+
+		rejected := 0
+		for _, d := range details {
+			if d.Rejected == "" {
+				continue
+			}
+			rejected++
+			for _, re := range d.Resources {
+				Expect(re.Reason).To(Equal(quotacontroller.EffectReasonRejected))
+				Expect(re.Applied).To(BeNil())
+			}
+		}
+		Expect(rejected).To(Equal(len(details) - 1))
+	})
+
+	It("should store the effect details in an annotation on the QuotaIncrease", func() {
+		env := defaultTestSetup(quotav1alpha1.MAXIMUM, false, "testdata", "test-01")
+		ns := &corev1.Namespace{}
+		ns.SetName("ns-project")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		qi := &quotav1alpha1.QuotaIncrease{}
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: "qi-project-med"}, qi)).To(Succeed())
+		Expect(qi.Annotations).To(HaveKey(quotav1alpha1.EffectDetailsAnnotation))
+		details := &quotacontroller.QuotaIncreaseEffect{}
+		Expect(json.Unmarshal([]byte(qi.Annotations[quotav1alpha1.EffectDetailsAnnotation]), details)).To(Succeed())
+		Expect(details.Resources).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), HaveField("OvershadowedBy", "qi-project-max")))
+	})
+
+})
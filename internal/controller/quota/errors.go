@@ -0,0 +1,107 @@
+package quota
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+)
+
+// ErrorClass classifies reconcile errors by what is required to resolve them.
+type ErrorClass string
+
+const (
+	// ErrorClassTransient is the class of errors which may resolve themselves, e.g. connection problems or conflicts.
+	// The reconcile is retried with exponential backoff. Errors which have not been classified explicitly are transient.
+	ErrorClassTransient ErrorClass = "transient"
+	// ErrorClassPermanent is the class of errors which can only be resolved by changing the reconciled objects, e.g. QuotaIncreases rejected by the API server.
+	ErrorClassPermanent ErrorClass = "permanent"
+	// ErrorClassConfig is the class of errors which can only be resolved by changing the QuotaServiceConfig, e.g. an invalid selector or template.
+	// These errors are reported in the 'Applied' condition of the config.
+	ErrorClassConfig ErrorClass = "config"
+)
+
+// errorClassPriority orders the error classes for errors which combine multiple errors.
+// The class with the lowest value wins, so that a combined error is retried if any of its errors is transient.
+var errorClassPriority = map[ErrorClass]int{
+	ErrorClassTransient: 0,
+	ErrorClassConfig:    1,
+	ErrorClassPermanent: 2,
+}
+
+// ReconcileError is an error with an explicit class.
+type ReconcileError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ReconcileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ReconcileError) Unwrap() error {
+	return e.Err
+}
+
+// NewPermanentError marks the given error as permanent.
+func NewPermanentError(err error) error {
+	return &ReconcileError{Class: ErrorClassPermanent, Err: err}
+}
+
+// NewConfigError marks the given error as caused by the QuotaServiceConfig.
+func NewConfigError(err error) error {
+	return &ReconcileError{Class: ErrorClassConfig, Err: err}
+}
+
+// ErrorClassOf returns the class of the given error.
+// For errors which combine multiple errors, e.g. via errors.Join, transient errors take precedence over config errors, which take precedence over permanent ones.
+// Unclassified errors are transient.
+func ErrorClassOf(err error) ErrorClass {
+	switch e := err.(type) {
+	case *ReconcileError:
+		return e.Class
+	case interface{ Unwrap() []error }:
+		var res ErrorClass
+		for _, inner := range e.Unwrap() {
+			if inner == nil {
+				continue
+			}
+			if class := ErrorClassOf(inner); res == "" || errorClassPriority[class] < errorClassPriority[res] {
+				res = class
+			}
+		}
+		if res != "" {
+			return res
+		}
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return ErrorClassOf(inner)
+		}
+	}
+	return ErrorClassTransient
+}
+
+// reconcileResult maps the error of a reconcile to the result for controller-runtime, depending on its class.
+// Without an error, the given result is returned. Transient errors are returned as they are and retried with backoff. Permanent and config errors are not retried with backoff, because retrying does not help:
+// if retryAfter is 0, they are returned as terminal errors, so that the object is only reconciled again when it or the config changes,
+// otherwise the reconcile is repeated after retryAfter, which is meant for objects that are not watched.
+// The result is recorded in the error rate tracker and the ConfigWatcher, which reports config errors in the status of the config, and errors are counted per class.
+func (r *QuotaController) reconcileResult(ctx context.Context, controllerName, object string, res reconcile.Result, err error, retryAfter time.Duration) (reconcile.Result, error) {
+	r.ErrorRate.Record(err)
+	r.Configs.RecordResult(object, err)
+	if err == nil {
+		return res, nil
+	}
+	class := ErrorClassOf(err)
+	reconcileErrorsTotal.WithLabelValues(controllerName, string(class)).Inc()
+	if class == ErrorClassTransient {
+		return reconcile.Result{}, err
+	}
+	if retryAfter > 0 {
+		logging.FromContextOrPanic(ctx).Error(err, "Reconcile failed, retrying after the resync interval", "errorClass", class, "retryAfter", retryAfter)
+		return reconcile.Result{RequeueAfter: retryAfter}, nil
+	}
+	return reconcile.Result{}, reconcile.TerminalError(err)
+}
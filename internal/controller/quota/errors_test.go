@@ -0,0 +1,143 @@
+package quota_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotainstall "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Reconcile Errors", func() {
+
+	DescribeTable("should classify errors",
+		func(err error, expected quotacontroller.ErrorClass) {
+			Expect(quotacontroller.ErrorClassOf(err)).To(Equal(expected))
+		},
+		Entry("unclassified errors are transient", errors.New("boom"), quotacontroller.ErrorClassTransient),
+		Entry("wrapped config errors", fmt.Errorf("outer: %w", quotacontroller.NewConfigError(errors.New("boom"))), quotacontroller.ErrorClassConfig),
+		Entry("wrapped permanent errors", fmt.Errorf("outer: %w", quotacontroller.NewPermanentError(errors.New("boom"))), quotacontroller.ErrorClassPermanent),
+		Entry("transient errors take precedence in joined errors", errors.Join(quotacontroller.NewPermanentError(errors.New("a")), errors.New("b")), quotacontroller.ErrorClassTransient),
+		Entry("config errors take precedence over permanent ones", fmt.Errorf("outer: %w", errors.Join(quotacontroller.NewPermanentError(errors.New("a")), quotacontroller.NewConfigError(errors.New("b")))), quotacontroller.ErrorClassConfig),
+	)
+
+	Context("Config errors", func() {
+
+		var env *testutils.ComplexEnvironment
+		var rejectResourceQuotas bool
+
+		getConfig := func() *quotav1alpha1.QuotaServiceConfig {
+			cfg := &quotav1alpha1.QuotaServiceConfig{}
+			cfg.SetName(providerName)
+			ExpectWithOffset(1, env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+			return cfg
+		}
+
+		BeforeEach(func() {
+			rejectResourceQuotas = true
+			testDataPath := filepath.Join("testdata", "test-01")
+			env = testutils.NewComplexEnvironmentBuilder().
+				WithInitObjectPath(platformCluster, testDataPath, "platform").
+				WithInitObjectPath(onboardingCluster, testDataPath, "onboarding").
+				WithFakeClient(platformCluster, quotainstall.InstallOperatorAPIsPlatform(runtime.NewScheme())).
+				WithFakeClient(onboardingCluster, quotainstall.InstallOperatorAPIsOnboarding(runtime.NewScheme())).
+				WithFakeClientBuilderCall(platformCluster, "WithStatusSubresource", &quotav1alpha1.QuotaServiceConfig{}).
+				WithFakeClientBuilderCall(onboardingCluster, "WithReturnManagedFields").
+				WithFakeClientBuilderCall(onboardingCluster, "WithInterceptorFuncs", interceptor.Funcs{
+					// simulates a template which is rejected by the API server
+					Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+						if rq, ok := obj.(*corev1ac.ResourceQuotaApplyConfiguration); ok && rejectResourceQuotas {
+							return apierrors.NewInvalid(schema.GroupKind{Kind: "ResourceQuota"}, *rq.Name, field.ErrorList{field.Invalid(field.NewPath("spec", "hard"), "", "invalid quantity")})
+						}
+						return c.Apply(ctx, obj, opts...)
+					},
+				}).
+				WithReconcilerConstructor(rec, func(c ...client.Client) reconcile.Reconciler {
+					return quotacontroller.NewQuotaController(clusters.NewTestClusterFromClient(platformCluster, c[0]), clusters.NewTestClusterFromClient(onboardingCluster, c[1]), providerName)
+				}, platformCluster, onboardingCluster).
+				Build()
+			registerConfigWatcher(env, env.Reconciler(rec).(*quotacontroller.QuotaController))
+			updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+				for i := range cfg.Spec.Quotas {
+					cfg.Spec.Quotas[i].Mode = quotav1alpha1.CUMULATIVE
+				}
+			})
+		})
+
+		It("should not retry reconciles which failed because of the config and report them in the 'Applied' condition", func() {
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			_, err := env.Reconciler(rec).Reconcile(env.Ctx, testutils.RequestFromObject(ns))
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue(), "config errors must not be retried with backoff")
+			Expect(quotacontroller.ErrorClassOf(err)).To(Equal(quotacontroller.ErrorClassConfig))
+
+			env.ShouldReconcile(cfgRec, testutils.RequestFromObject(getConfig()))
+			cond := meta.FindStatusCondition(getConfig().Status.Conditions, quotav1alpha1.ConfigConditionApplied)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(quotav1alpha1.ConfigReasonConfigError))
+			Expect(cond.Message).To(ContainSubstring("namespace 'ns-normal'"))
+
+			// once the namespace can be reconciled, the condition recovers
+			rejectResourceQuotas = false
+			env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+			env.ShouldReconcile(cfgRec, testutils.RequestFromObject(getConfig()))
+			cond = meta.FindStatusCondition(getConfig().Status.Conditions, quotav1alpha1.ConfigConditionApplied)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(quotav1alpha1.ConfigReasonApplied))
+		})
+
+		It("should retry transient errors with backoff", func() {
+			// the namespace can't be read, because the API server is unavailable
+			qc := env.Reconciler(rec).(*quotacontroller.QuotaController)
+			qc.OnboardingCache = &failingReader{}
+			ns := &corev1.Namespace{}
+			ns.SetName("ns-normal")
+			_, err := qc.Reconcile(env.Ctx, testutils.RequestFromObject(ns))
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse())
+			Expect(quotacontroller.ErrorClassOf(err)).To(Equal(quotacontroller.ErrorClassTransient))
+
+			// transient errors are not reported in the config status
+			env.ShouldReconcile(cfgRec, testutils.RequestFromObject(getConfig()))
+			cond := meta.FindStatusCondition(getConfig().Status.Conditions, quotav1alpha1.ConfigConditionApplied)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+	})
+
+})
+
+// failingReader is a client.Reader which fails all requests, like an unreachable API server.
+type failingReader struct{}
+
+func (r *failingReader) Get(_ context.Context, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	return apierrors.NewServiceUnavailable(fmt.Sprintf("unable to get '%s'", key.String()))
+}
+
+func (r *failingReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return apierrors.NewServiceUnavailable("unable to list")
+}
@@ -0,0 +1,58 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+)
+
+// recordEvent creates an Event for the given object in the given cluster.
+// The events are written directly instead of using an EventRecorder, because the reconciled objects may reside in different clusters.
+// Errors are only logged, as failing to record an event should not fail the reconciliation.
+func (r *QuotaController) recordEvent(ctx context.Context, tgt *clusters.Cluster, obj client.Object, eventType, reason, message string) {
+	log := logging.FromContextOrPanic(ctx)
+
+	gvk, err := apiutil.GVKForObject(obj, tgt.Scheme())
+	if err != nil {
+		log.Error(err, "Unable to determine GroupVersionKind for event", "reason", reason)
+		return
+	}
+	// events for cluster-scoped objects are stored in the default namespace
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	now := metav1.Now()
+	ev := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s.", obj.GetName()),
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      gvk.GroupVersion().String(),
+			Kind:            gvk.Kind,
+			Name:            obj.GetName(),
+			Namespace:       obj.GetNamespace(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Type:                eventType,
+		Reason:              reason,
+		Message:             message,
+		Source:              corev1.EventSource{Component: r.FieldManager()},
+		ReportingController: r.FieldManager(),
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+	if err := tgt.Client().Create(ctx, ev); err != nil {
+		log.Error(err, "Unable to record event", "reason", reason, "message", message)
+	}
+}
@@ -0,0 +1,140 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	// DefaultErrorRateWindow is the default time window over which the reconcile error rate is computed.
+	DefaultErrorRateWindow = 5 * time.Minute
+	// DefaultErrorRateThreshold is the default reconcile error rate above which the controller is reported as not ready.
+	DefaultErrorRateThreshold = 0.5
+	// DefaultErrorRateMinSamples is the default number of reconciles within the window which are required before the error rate is evaluated.
+	DefaultErrorRateMinSamples = 10
+
+	// errorRateBuckets is the number of buckets the window is split into.
+	errorRateBuckets = 10
+	// cacheSyncCheckTimeout is the maximum time the cache sync check waits for the caches to be synced.
+	cacheSyncCheckTimeout = time.Second
+)
+
+// CacheSyncCheck returns a readiness check which fails if the given cache has not been started or synced yet.
+func CacheSyncCheck(c cache.Cache) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncCheckTimeout)
+		defer cancel()
+		if !c.WaitForCacheSync(ctx) {
+			return fmt.Errorf("cache not synced")
+		}
+		return nil
+	}
+}
+
+// ErrorRateTracker keeps track of the reconcile results within a sliding time window.
+// The window is split into buckets, so the memory usage does not depend on the number of reconciles.
+// A nil ErrorRateTracker ignores all results and always reports a healthy state.
+type ErrorRateTracker struct {
+	// Window is the time window over which the error rate is computed.
+	Window time.Duration
+	// Threshold is the error rate (between 0 and 1) above which the check fails.
+	Threshold float64
+	// MinSamples is the minimum number of reconciles within the window before the error rate is evaluated.
+	MinSamples int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	lock    sync.Mutex
+	buckets [errorRateBuckets]errorRateBucket
+}
+
+type errorRateBucket struct {
+	start  time.Time
+	total  int
+	failed int
+}
+
+// NewErrorRateTracker creates a new ErrorRateTracker.
+func NewErrorRateTracker(window time.Duration, threshold float64, minSamples int) *ErrorRateTracker {
+	return &ErrorRateTracker{
+		Window:     window,
+		Threshold:  threshold,
+		MinSamples: minSamples,
+		Now:        time.Now,
+	}
+}
+
+// Record records the result of a reconcile. A non-nil error counts as failure.
+func (t *ErrorRateTracker) Record(err error) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	b := t.currentBucket()
+	b.total++
+	if err != nil {
+		b.failed++
+	}
+}
+
+// ErrorRate returns the error rate and the number of reconciles within the window.
+func (t *ErrorRateTracker) ErrorRate() (float64, int) {
+	if t == nil {
+		return 0, 0
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	oldest := t.now().Add(-t.Window)
+	total, failed := 0, 0
+	for _, b := range t.buckets {
+		if b.start.After(oldest) {
+			total += b.total
+			failed += b.failed
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(failed) / float64(total), total
+}
+
+// Check is a readiness check which fails if the error rate within the window exceeds the threshold.
+func (t *ErrorRateTracker) Check(_ *http.Request) error {
+	rate, samples := t.ErrorRate()
+	if t == nil || samples < t.MinSamples {
+		return nil
+	}
+	if rate > t.Threshold {
+		return fmt.Errorf("reconcile error rate %.2f within the last %s exceeds threshold %.2f (%d reconciles)", rate, t.Window, t.Threshold, samples)
+	}
+	return nil
+}
+
+// currentBucket returns the bucket for the current time, resetting it if it belongs to an older period.
+// Must be called with the lock held.
+func (t *ErrorRateTracker) currentBucket() *errorRateBucket {
+	bucketSize := t.Window / errorRateBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	start := t.now().Truncate(bucketSize)
+	b := &t.buckets[(start.UnixNano()/int64(bucketSize))%errorRateBuckets]
+	if !b.start.Equal(start) {
+		*b = errorRateBucket{start: start}
+	}
+	return b
+}
+
+func (t *ErrorRateTracker) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
@@ -0,0 +1,73 @@
+package quota_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Readiness Checks", func() {
+
+	Context("Reconcile Error Rate", func() {
+
+		var now time.Time
+		var tracker *quotacontroller.ErrorRateTracker
+
+		BeforeEach(func() {
+			now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			tracker = quotacontroller.NewErrorRateTracker(10*time.Minute, 0.5, 4)
+			tracker.Now = func() time.Time { return now }
+		})
+
+		It("should only fail if the error rate exceeds the threshold and enough samples have been recorded", func() {
+			tracker.Record(errors.New("fail"))
+			tracker.Record(errors.New("fail"))
+			tracker.Record(errors.New("fail"))
+			// not enough samples yet
+			Expect(tracker.Check(nil)).To(Succeed())
+
+			tracker.Record(nil)
+			rate, samples := tracker.ErrorRate()
+			Expect(samples).To(Equal(4))
+			Expect(rate).To(BeNumerically("~", 0.75))
+			Expect(tracker.Check(nil)).To(MatchError(ContainSubstring("exceeds threshold")))
+
+			tracker.Record(nil)
+			tracker.Record(nil)
+			Expect(tracker.Check(nil)).To(Succeed())
+		})
+
+		It("should forget results which are older than the window", func() {
+			for range 5 {
+				tracker.Record(errors.New("fail"))
+			}
+			Expect(tracker.Check(nil)).ToNot(Succeed())
+
+			now = now.Add(5 * time.Minute)
+			for range 5 {
+				tracker.Record(nil)
+			}
+			rate, samples := tracker.ErrorRate()
+			Expect(samples).To(Equal(10))
+			Expect(rate).To(BeNumerically("~", 0.5))
+
+			now = now.Add(6 * time.Minute)
+			rate, samples = tracker.ErrorRate()
+			Expect(samples).To(Equal(5))
+			Expect(rate).To(BeZero())
+			Expect(tracker.Check(nil)).To(Succeed())
+		})
+
+		It("should ignore results if the tracker is nil", func() {
+			var nilTracker *quotacontroller.ErrorRateTracker
+			nilTracker.Record(errors.New("fail"))
+			Expect(nilTracker.Check(nil)).To(Succeed())
+		})
+
+	})
+
+})
@@ -0,0 +1,150 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+const (
+	// HistoryConfigMapName is the name of the ConfigMap in each reconciled namespace which contains the history of the hard limits of the generated ResourceQuota.
+	HistoryConfigMapName = "quota-history"
+	// HistoryDataKey is the key of the history ConfigMap's data which contains the history entries as JSON list, oldest first.
+	HistoryDataKey = "history"
+	// DefaultHistoryLimit is the default number of history entries which are kept per namespace.
+	DefaultHistoryLimit = 20
+)
+
+// HistoryEntry describes a single change of the hard limits of a generated ResourceQuota.
+type HistoryEntry struct {
+	// Timestamp is the time at which the change has been applied.
+	Timestamp metav1.Time `json:"timestamp"`
+	// ConfigGeneration is the generation of the QuotaServiceConfig which has been applied.
+	ConfigGeneration int64 `json:"configGeneration"`
+	// QuotaDefinition is the name of the quota definition which has been applied.
+	QuotaDefinition string `json:"quotaDefinition"`
+	// Changes contains the hard limits which have changed, sorted by resource name.
+	Changes []HardLimitChange `json:"changes"`
+	// QuotaIncreases contains the QuotaIncreases which contribute to the changed hard limits, sorted by name.
+	QuotaIncreases []HistoryQuotaIncrease `json:"quotaIncreases,omitempty"`
+}
+
+// HistoryQuotaIncrease is a QuotaIncrease which contributes to the changed hard limits of a HistoryEntry.
+type HistoryQuotaIncrease struct {
+	// Name is the name of the QuotaIncrease.
+	Name string `json:"name"`
+	// Effect is the part of the QuotaIncrease's effect which affects the changed hard limits.
+	Effect corev1.ResourceList `json:"effect"`
+}
+
+// configGeneration returns the generation of the QuotaServiceConfig which is applied to the given namespace.
+func (r *QuotaController) configGeneration(tgt *clusters.Cluster, ns *corev1.Namespace) int64 {
+	if tgt == r.OnboardingCluster {
+		return r.Configs.ForNamespace(ns).Generation
+	}
+	// staged rollouts only affect onboarding namespaces
+	return r.Configs.Active().Generation
+}
+
+// recordHistory logs the change of the hard limits from the live to the desired ResourceQuota, which has just been applied, and appends it to the history ConfigMap of the namespace.
+// The live ResourceQuota is nil if it has just been created. Nothing is recorded if the hard limits did not change.
+// Errors are only logged, because the ResourceQuota has already been applied and retrying the reconcile would not record the change.
+func (r *QuotaController) recordHistory(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, desired, live *corev1.ResourceQuota, effects map[string]corev1.ResourceList) {
+	log := logging.FromContextOrPanic(ctx)
+
+	var oldHard corev1.ResourceList
+	if live != nil {
+		oldHard = live.Spec.Hard
+	}
+	entry := HistoryEntry{
+		Timestamp:        metav1.Now(),
+		ConfigGeneration: r.configGeneration(tgt, namespace),
+		QuotaDefinition:  qdef.Name,
+		Changes:          hardLimitChanges(oldHard, desired.Spec.Hard),
+	}
+	if len(entry.Changes) == 0 {
+		return
+	}
+	changes := make([]string, 0, len(entry.Changes))
+	for _, c := range entry.Changes {
+		changes = append(changes, c.String())
+	}
+	qiNames := []string{}
+	for _, qiName := range sets.List(sets.KeySet(effects)) {
+		effect := corev1.ResourceList{}
+		for _, c := range entry.Changes {
+			if q, ok := effects[qiName][c.Resource]; ok {
+				effect[c.Resource] = q
+			}
+		}
+		if len(effect) > 0 {
+			entry.QuotaIncreases = append(entry.QuotaIncreases, HistoryQuotaIncrease{Name: qiName, Effect: effect})
+			qiNames = append(qiNames, qiName)
+		}
+	}
+	log.Info("Hard limits of ResourceQuota changed", "resourceQuota", desired.Name, "configGeneration", entry.ConfigGeneration, "changes", changes, "quotaIncreases", qiNames)
+
+	if r.HistoryLimit <= 0 {
+		return
+	}
+	if err := r.appendHistory(ctx, tgt, namespace, entry); err != nil {
+		log.Error(err, "Unable to persist quota history", "configMap", HistoryConfigMapName)
+	}
+}
+
+// appendHistory appends the entry to the history ConfigMap of the namespace, dropping the oldest entries if there are more than HistoryLimit.
+// The ConfigMap is created if it does not exist.
+// An existing ConfigMap which does not carry the managed-by label of this controller belongs to someone else and is not modified, which is reported via an event on the namespace.
+func (r *QuotaController) appendHistory(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, entry HistoryEntry) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	entries := []HistoryEntry{}
+	cm := &corev1.ConfigMap{}
+	if err := tgt.Client().Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: HistoryConfigMapName}, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("unable to fetch history ConfigMap: %w", err)
+		}
+	} else if managedBy, _ := ctrlutils.GetLabel(cm, quotav1alpha1.ManagedByLabel); managedBy != r.ProviderName {
+		msg := fmt.Sprintf("Quota history is not recorded, because ConfigMap '%s' has not been created by this controller", HistoryConfigMapName)
+		log.Info(msg, "configMap", HistoryConfigMapName, "managedBy", managedBy)
+		r.recordEvent(ctx, tgt, namespace, corev1.EventTypeWarning, "HistoryNotRecorded", msg)
+		return nil
+	} else if data := cm.Data[HistoryDataKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			// a corrupted history must not prevent new entries from being recorded
+			log.Info("Unable to parse history ConfigMap, discarding the existing entries", "configMap", HistoryConfigMapName, "error", err.Error())
+			entries = []HistoryEntry{}
+		}
+	}
+	entries = append(entries, entry)
+	if len(entries) > r.HistoryLimit {
+		entries = entries[len(entries)-r.HistoryLimit:]
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling quota history: %w", err)
+	}
+
+	owner, err := controllerReference(tgt, namespace)
+	if err != nil {
+		return err
+	}
+	cmac := corev1ac.ConfigMap(HistoryConfigMapName, namespace.Name).
+		WithLabels(map[string]string{quotav1alpha1.ManagedByLabel: r.ProviderName}).
+		WithOwnerReferences(owner).
+		WithData(map[string]string{HistoryDataKey: string(data)})
+	return r.apply(ctx, tgt, namespace, cmac, "ConfigMap", HistoryConfigMapName)
+}
@@ -0,0 +1,145 @@
+package quota_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("ResourceQuota History", func() {
+
+	var env *testutils.ComplexEnvironment
+	var qc *quotacontroller.QuotaController
+	var ns *corev1.Namespace
+
+	// history returns the entries of the history ConfigMap of the test namespace.
+	history := func() []quotacontroller.HistoryEntry {
+		cm := &corev1.ConfigMap{}
+		ExpectWithOffset(1, env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: quotacontroller.HistoryConfigMapName}, cm)).To(Succeed())
+		entries := []quotacontroller.HistoryEntry{}
+		ExpectWithOffset(1, json.Unmarshal([]byte(cm.Data[quotacontroller.HistoryDataKey]), &entries)).To(Succeed())
+		return entries
+	}
+
+	setBaseQuota := func(value string) {
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("all").ResourceQuotaTemplate.Spec.Hard["count/serviceaccounts"] = resource.MustParse(value)
+		})
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		qc = env.Reconciler(rec).(*quotacontroller.QuotaController)
+		ns = &corev1.Namespace{}
+		ns.SetName("ns-normal")
+	})
+
+	It("should record the creation and changes of the ResourceQuota", func() {
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		entries := history()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].QuotaDefinition).To(Equal("all"))
+		Expect(entries[0].ConfigGeneration).To(Equal(qc.Configs.Active().Generation))
+		Expect(entries[0].Timestamp.IsZero()).To(BeFalse())
+		Expect(entries[0].Changes).To(HaveLen(1))
+		Expect(entries[0].Changes[0].Resource).To(Equal(corev1.ResourceName("count/serviceaccounts")))
+		Expect(entries[0].Changes[0].Old).To(BeNil())
+		Expect(*entries[0].Changes[0].New).To(matchNumericQuantity(3))
+		Expect(entries[0].QuotaIncreases).To(BeEmpty())
+
+		// nothing is recorded if the hard limits don't change
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(history()).To(HaveLen(1))
+
+		cm := &corev1.ConfigMap{}
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: quotacontroller.HistoryConfigMapName}, cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue(quotav1alpha1.ManagedByLabel, providerName))
+		Expect(cm.OwnerReferences).To(ContainElement(HaveField("Name", ns.Name)))
+
+		setBaseQuota("4")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		entries = history()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1].ConfigGeneration).To(Equal(qc.Configs.Active().Generation))
+		Expect(entries[1].ConfigGeneration).To(BeNumerically(">", entries[0].ConfigGeneration))
+		Expect(*entries[1].Changes[0].Old).To(matchNumericQuantity(3))
+		Expect(*entries[1].Changes[0].New).To(matchNumericQuantity(4))
+	})
+
+	It("should list the QuotaIncreases which contribute to the changed hard limits", func() {
+		qi := &quotav1alpha1.QuotaIncrease{}
+		qi.SetName("more-sa")
+		qi.SetNamespace(ns.Name)
+		qi.Spec.Hard = corev1.ResourceList{"count/serviceaccounts": resource.MustParse("2")}
+		Expect(env.Client(onboardingCluster).Create(env.Ctx, qi)).To(Succeed())
+
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		entries := history()
+		Expect(entries).To(HaveLen(1))
+		Expect(*entries[0].Changes[0].New).To(matchNumericQuantity(5))
+		Expect(entries[0].QuotaIncreases).To(HaveLen(1))
+		Expect(entries[0].QuotaIncreases[0].Name).To(Equal("more-sa"))
+		Expect(entries[0].QuotaIncreases[0].Effect).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchNumericQuantity(2)))
+	})
+
+	It("should only keep the configured number of entries", func() {
+		qc.HistoryLimit = 2
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		setBaseQuota("4")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		setBaseQuota("5")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+
+		entries := history()
+		Expect(entries).To(HaveLen(2))
+		Expect(*entries[0].Changes[0].New).To(matchNumericQuantity(4))
+		Expect(*entries[1].Changes[0].New).To(matchNumericQuantity(5))
+	})
+
+	It("should not modify a history ConfigMap which has not been created by the controller", func() {
+		cm := &corev1.ConfigMap{}
+		cm.SetName(quotacontroller.HistoryConfigMapName)
+		cm.SetNamespace(ns.Name)
+		cm.Data = map[string]string{"owner": "tenant"}
+		Expect(env.Client(onboardingCluster).Create(env.Ctx, cm)).To(Succeed())
+
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(cm.Labels).ToNot(HaveKey(quotav1alpha1.ManagedByLabel))
+		Expect(cm.OwnerReferences).To(BeEmpty())
+		Expect(cm.Data).To(Equal(map[string]string{"owner": "tenant"}))
+
+		evs := &corev1.EventList{}
+		Expect(env.Client(onboardingCluster).List(env.Ctx, evs)).To(Succeed())
+		Expect(evs.Items).To(ContainElement(And(
+			HaveField("Reason", "HistoryNotRecorded"),
+			HaveField("InvolvedObject.Name", ns.Name),
+		)))
+	})
+
+	It("should not write the history if it is disabled or in audit mode", func() {
+		qc.HistoryLimit = 0
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		cm := &corev1.ConfigMap{}
+		err := env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: quotacontroller.HistoryConfigMapName}, cm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		qc.HistoryLimit = quotacontroller.DefaultHistoryLimit
+		qc.AuditLog = quotacontroller.NewAuditLog()
+		setBaseQuota("4")
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		err = env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKey{Namespace: ns.Name, Name: quotacontroller.HistoryConfigMapName}, cm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+})
@@ -0,0 +1,36 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// ManagedByIndex is the name of the field index on namespaces and ResourceQuotas which contains the value of their managed-by label.
+// It allows to list the objects managed by a specific instance of this platform service from the cache without iterating over all objects.
+// Note that listing objects within a namespace does not require a field index, because the cache indexes all objects by namespace.
+const ManagedByIndex = "metadata.labels.managed-by"
+
+// IndexManagedBy is the indexer function for the ManagedByIndex.
+func IndexManagedBy(obj client.Object) []string {
+	if value, ok := obj.GetLabels()[quotav1alpha1.ManagedByLabel]; ok {
+		return []string{value}
+	}
+	return nil
+}
+
+// SetupFieldIndexes registers the field indexes which are used by the API server at the given indexer.
+// The quota controller does not require field indexes: it only lists objects within a namespace, and the config change handlers have to match all namespaces against the selectors of the quota definitions.
+// Must be called before the cache is started.
+func SetupFieldIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, obj := range []client.Object{&corev1.Namespace{}, &corev1.ResourceQuota{}} {
+		if err := indexer.IndexField(ctx, obj, ManagedByIndex, IndexManagedBy); err != nil {
+			return fmt.Errorf("error registering field index '%s' for %T: %w", ManagedByIndex, obj, err)
+		}
+	}
+	return nil
+}
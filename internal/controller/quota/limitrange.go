@@ -0,0 +1,153 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/inf.v0"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// reconcileLimitRange applies the LimitRange for the given quota definition in the namespace.
+// If the quota definition does not contain a LimitRange template, a previously generated LimitRange with the same name is deleted.
+// The given ResourceQuota is expected to be the one that was generated for the namespace and is used to scale the LimitRange, if configured.
+func (r *QuotaController) reconcileLimitRange(ctx context.Context, tgt *clusters.Cluster, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, rq *corev1.ResourceQuota) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	lr := &corev1.LimitRange{}
+	lr.SetName(qdef.Name)
+	lr.SetNamespace(namespace.Name)
+
+	if qdef.LimitRangeTemplate == nil {
+		if err := r.reader(tgt).Get(ctx, client.ObjectKeyFromObject(lr), lr); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("unable to fetch LimitRange: %w", err)
+		}
+		if managedBy, _ := ctrlutils.GetLabel(lr, quotav1alpha1.ManagedByLabel); managedBy != r.ProviderName {
+			// not generated by this controller
+			return nil
+		}
+		change := &AuditChange{Kind: "LimitRange", Name: lr.Name, Action: AuditActionDelete, Details: "quota definition has no LimitRange template"}
+		return r.write(ctx, change, func() error {
+			log.Info("Deleting LimitRange, because the quota definition does not contain a LimitRange template anymore", "limitRange", lr.Name)
+			if err := tgt.Client().Delete(ctx, lr); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("error deleting LimitRange: %w", err)
+			}
+			return nil
+		})
+	}
+
+	computedLr := r.computeLimitRange(namespace, qdef, rq)
+	owner, err := controllerReference(tgt, namespace)
+	if err != nil {
+		return err
+	}
+	var live *corev1.LimitRange
+	if r.auditing() {
+		// only required to determine whether applying the LimitRange would change anything
+		live = &corev1.LimitRange{}
+		if err := r.reader(tgt).Get(ctx, client.ObjectKeyFromObject(lr), live); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("unable to fetch LimitRange: %w", err)
+			}
+			live = nil
+		}
+	}
+	return r.write(ctx, limitRangeChange(computedLr, live), func() error {
+		log.Info("Applying LimitRange", "limitRange", lr.Name)
+		return r.apply(ctx, tgt, namespace, limitRangeApplyConfiguration(computedLr).WithOwnerReferences(owner), "LimitRange", lr.Name)
+	})
+}
+
+// computeLimitRange takes the base LimitRange from the config and scales it according to the given ResourceQuota, if configured.
+// Scaled default requests are capped at the default limits and the maximum.
+func (r *QuotaController) computeLimitRange(namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, rq *corev1.ResourceQuota) *corev1.LimitRange {
+	lr := qdef.BaseLimitRange()
+	lr.SetNamespace(namespace.Name)
+	if lr.Labels == nil {
+		lr.Labels = map[string]string{}
+	}
+	lr.Labels[quotav1alpha1.ManagedByLabel] = r.ProviderName
+	lr.Labels[quotav1alpha1.QuotaDefinitionLabel] = qdef.Name
+
+	if !qdef.LimitRangeTemplate.ScaleWithQuotaIncreases || rq == nil {
+		return lr
+	}
+	base := qdef.BaseResourceQuota().Spec.Hard
+	for i := range lr.Spec.Limits {
+		item := &lr.Spec.Limits[i]
+		scaleResourceList(item.Max, base, rq.Spec.Hard, limitsQuotaNames)
+		scaleResourceList(item.Default, base, rq.Spec.Hard, limitsQuotaNames)
+		scaleResourceList(item.DefaultRequest, base, rq.Spec.Hard, requestsQuotaNames)
+		// requests and limits may have been scaled by different factors, but the API server rejects default requests above the default limits or the maximum
+		capResourceList(item.DefaultRequest, item.Default)
+		capResourceList(item.DefaultRequest, item.Max)
+	}
+	return lr
+}
+
+// capResourceList reduces each quantity in the given list to the corresponding quantity in caps, if it exceeds it.
+// Quantities without a corresponding cap are not modified.
+func capResourceList(data, caps corev1.ResourceList) {
+	for res, quantity := range data {
+		if limit, ok := caps[res]; ok && quantity.Cmp(limit) > 0 {
+			data[res] = limit.DeepCopy()
+		}
+	}
+}
+
+// limitsQuotaNames returns the names of the quotas which constrain the limits for the given resource.
+func limitsQuotaNames(res corev1.ResourceName) []corev1.ResourceName {
+	return []corev1.ResourceName{corev1.ResourceName("limits." + res)}
+}
+
+// requestsQuotaNames returns the names of the quotas which constrain the requests for the given resource, in order of precedence.
+func requestsQuotaNames(res corev1.ResourceName) []corev1.ResourceName {
+	return []corev1.ResourceName{corev1.ResourceName("requests." + res), res}
+}
+
+// scaleResourceList multiplies each quantity in the given list with the factor by which the corresponding quota has been increased from base to actual.
+// The first quota name returned by quotaNames which exists in both base and actual is used.
+// Quantities for which no such quota exists, or whose quota has not been increased, are not modified.
+// The scaled quantities are rounded down to the precision returned by scalePrecision.
+func scaleResourceList(data, base, actual corev1.ResourceList, quotaNames func(corev1.ResourceName) []corev1.ResourceName) {
+	for res, quantity := range data {
+		for _, qn := range quotaNames(res) {
+			baseQ, ok := base[qn]
+			if !ok {
+				continue
+			}
+			actualQ, ok := actual[qn]
+			if !ok {
+				continue
+			}
+			if baseQ.Sign() > 0 && actualQ.Cmp(baseQ) > 0 {
+				// decimal arithmetic, because the milli values of large quantities don't fit into an int64
+				scaled := new(inf.Dec).Mul(quantity.AsDec(), actualQ.AsDec())
+				scaled.QuoRound(scaled, baseQ.AsDec(), scalePrecision(res), inf.RoundDown)
+				data[res] = *resource.NewDecimalQuantity(*scaled, quantity.Format)
+			}
+			break
+		}
+	}
+}
+
+// scalePrecision returns the number of decimal places to which scaled quantities of the given resource are rounded.
+// CPU can be specified in millicores, all other resources, e.g. memory and storage, in whole units.
+func scalePrecision(res corev1.ResourceName) inf.Scale {
+	if res == corev1.ResourceCPU {
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// applyQuotaIncreaseLimits checks the given QuotaIncreases against the limits configured in the quota definition.
+// It returns a list containing only the accepted QuotaIncreases (in their original order) and a mapping from the names of the rejected QuotaIncreases to the reason of their rejection.
+// The QuotaIncreases are evaluated in the order of their creation timestamps (ties are broken by name), so older QuotaIncreases take precedence over newer ones.
+// A QuotaIncrease is rejected if accepting it would exceed the maximum number of QuotaIncreases or would increase the quota for any resource by more than the configured maximum.
+func (r *QuotaController) applyQuotaIncreaseLimits(ctx context.Context, namespace *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, qis *quotav1alpha1.QuotaIncreaseList) (*quotav1alpha1.QuotaIncreaseList, map[string]string) {
+	log := logging.FromContextOrPanic(ctx)
+	rejections := map[string]string{}
+	limits := qdef.QuotaIncreaseLimits
+	if limits == nil {
+		return qis, rejections
+	}
+
+	ordered := make([]*quotav1alpha1.QuotaIncrease, len(qis.Items))
+	for i := range qis.Items {
+		ordered[i] = &qis.Items[i]
+	}
+	slices.SortStableFunc(ordered, func(a, b *quotav1alpha1.QuotaIncrease) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	// computeResourceQuota is called repeatedly with partial lists, its logs would only be confusing
+	computeCtx := logging.NewContextWithDiscard(ctx)
+	base := qdef.BaseResourceQuota().Spec.Hard
+	accepted := &quotav1alpha1.QuotaIncreaseList{}
+	for _, qi := range ordered {
+		if limits.MaxCount != nil && len(accepted.Items) >= int(*limits.MaxCount) {
+			rejections[qi.Name] = fmt.Sprintf("maximum number of QuotaIncreases (%d) exceeded", *limits.MaxCount)
+			continue
+		}
+		if len(limits.MaxIncrease) > 0 {
+			candidate := &quotav1alpha1.QuotaIncreaseList{Items: append(slices.Clone(accepted.Items), *qi)}
+			rq, _ := r.computeResourceQuota(computeCtx, namespace, qdef, candidate)
+			if reason := exceededIncrease(base, rq.Spec.Hard, limits.MaxIncrease); reason != "" {
+				rejections[qi.Name] = reason
+				continue
+			}
+		}
+		accepted.Items = append(accepted.Items, *qi)
+	}
+
+	if len(rejections) > 0 {
+		log.Info("Rejected QuotaIncreases due to the limits of the quota definition", "rejected", sets.List(sets.KeySet(rejections)))
+	}
+
+	// restore original order
+	res := &quotav1alpha1.QuotaIncreaseList{}
+	for _, qi := range qis.Items {
+		if _, ok := rejections[qi.Name]; !ok {
+			res.Items = append(res.Items, qi)
+		}
+	}
+	return res, rejections
+}
+
+// exceededIncrease returns a description of the first resource (in alphabetical order) for which the actual quota exceeds the base quota by more than the maximum increase.
+// Returns an empty string if no maximum is exceeded.
+func exceededIncrease(base, actual, maxIncrease corev1.ResourceList) string {
+	for _, res := range sets.List(sets.KeySet(maxIncrease)) {
+		actualQ, ok := actual[res]
+		if !ok {
+			continue
+		}
+		increase := actualQ.DeepCopy()
+		if baseQ, ok := base[res]; ok {
+			increase.Sub(baseQ)
+		}
+		maxQ := maxIncrease[res]
+		if increase.Cmp(maxQ) > 0 {
+			return fmt.Sprintf("maximum increase for '%s' (%s) exceeded", res, maxQ.String())
+		}
+	}
+	return ""
+}
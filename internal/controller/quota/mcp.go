@@ -0,0 +1,313 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+	crdutil "github.com/openmcp-project/controller-utils/pkg/crds"
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+	clustersv1alpha1 "github.com/openmcp-project/openmcp-operator/api/clusters/v1alpha1"
+	commonapi "github.com/openmcp-project/openmcp-operator/api/common"
+	openapiconst "github.com/openmcp-project/openmcp-operator/api/constants"
+	"github.com/openmcp-project/openmcp-operator/lib/clusteraccess/advanced"
+
+	"github.com/openmcp-project/platform-service-quota/api/crds"
+	providerscheme "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+const (
+	MCPControllerName = "mcp-quota"
+
+	// mcpAccessID is the ID under which the access to the MCP clusters is registered at the cluster access reconciler.
+	mcpAccessID = "mcp"
+	// DefaultMCPResyncInterval is the default interval after which the namespaces in an MCP cluster are re-evaluated.
+	DefaultMCPResyncInterval = 5 * time.Minute
+)
+
+// MCPClusterPermissions are the permissions requested for the MCP clusters.
+var MCPClusterPermissions = []clustersv1alpha1.PermissionsRequest{
+	{
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"apiextensions.k8s.io"},
+				Resources: []string{"customresourcedefinitions"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{quotav1alpha1.GroupName},
+				Resources: []string{"quotaincreases"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"watch", "get", "list", "update", "patch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"resourcequotas", "resourcequotas/status"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"limitranges"},
+				Verbs:     []string{"*"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"get", "create", "update", "patch"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"events"},
+				Verbs:     []string{"create", "patch"},
+			},
+		},
+	},
+}
+
+// NewMCPQuotaController creates a new MCPQuotaController instance.
+// It uses the given QuotaController for config handling and the actual quota logic.
+// The AccessRequests for the MCP clusters are created in the given provider namespace on the platform cluster.
+func NewMCPQuotaController(qc *QuotaController, providerNamespace string, resyncInterval time.Duration) *MCPQuotaController {
+	access := advanced.NewClusterAccessReconciler(qc.PlatformCluster.Client(), qc.ProviderName).
+		Register(advanced.ExistingCluster(mcpAccessID, mcpAccessID, func(req reconcile.Request, _ ...any) (*commonapi.ObjectReference, error) {
+			return &commonapi.ObjectReference{
+				Name:      req.Name,
+				Namespace: req.Namespace,
+			}, nil
+		}).
+			WithTokenAccess(&clustersv1alpha1.TokenConfig{Permissions: MCPClusterPermissions}).
+			WithScheme(providerscheme.InstallOperatorAPIsMCP(runtime.NewScheme())).
+			WithNamespaceGenerator(advanced.StaticNamespaceGenerator(providerNamespace)).
+			Build())
+	return &MCPQuotaController{
+		Quota:          qc,
+		ClusterAccess:  access,
+		ResyncInterval: resyncInterval,
+	}
+}
+
+// MCPQuotaController reconciles openMCP Cluster resources with the 'mcp' purpose on the platform cluster.
+// It applies all quota definitions with target 'mcp' whose cluster selector matches the Cluster to the namespaces within the MCP cluster.
+// Since the namespaces and QuotaIncreases within the MCP clusters are not watched, the clusters are re-evaluated periodically.
+type MCPQuotaController struct {
+	Quota          *QuotaController
+	ClusterAccess  advanced.ClusterAccessReconciler
+	ResyncInterval time.Duration
+}
+
+// Reconcile ensures access to the reconciled MCP cluster and reconciles all namespaces within it.
+// If the Cluster is gone or no quota definition targets it anymore, the access to it is released.
+// Since the namespaces within the MCP cluster are not watched, permanent and config errors are retried after the resync interval instead of with backoff.
+func (r *MCPQuotaController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContextOrPanic(ctx).WithName(MCPControllerName).WithValues("cluster", req.String())
+	ctx = logging.NewContext(ctx, log)
+	log.Debug("Reconcile triggered")
+
+	res, err := r.reconcile(ctx, req)
+	return r.Quota.reconcileResult(ctx, MCPControllerName, fmt.Sprintf("cluster '%s'", req.String()), res, err, r.ResyncInterval)
+}
+
+// reconcile contains the actual logic of Reconcile.
+func (r *MCPQuotaController) reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := logging.FromContextOrPanic(ctx)
+
+	if r.Quota.Configs.Active() == nil {
+		// all clusters are reconciled as soon as the config watcher has loaded a valid config
+		log.Debug("No valid QuotaServiceConfig loaded yet, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	// fetch Cluster
+	c := &clustersv1alpha1.Cluster{}
+	if err := r.Quota.PlatformCluster.Client().Get(ctx, req.NamespacedName, c); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("unable to fetch Cluster: %w", err)
+		}
+		log.Debug("Cluster not found, releasing access")
+		r.Quota.Configs.ForgetClusterDrift(mcpClusterID(req), nil)
+		return r.ClusterAccess.ReconcileDelete(ctx, req)
+	}
+
+	// staged rollouts only cover onboarding namespaces, MCP clusters always use the active config
+	qdefs, err := quotaDefinitionsForCluster(r.Quota.Configs.compiledSelectors(), r.Quota.Configs.Active(), c)
+	if err != nil {
+		return ctrl.Result{}, NewConfigError(err)
+	}
+	if !c.DeletionTimestamp.IsZero() || len(qdefs) == 0 {
+		log.Debug("Cluster is being deleted or not targeted by any quota definition, releasing access")
+		r.Quota.Configs.ForgetClusterDrift(mcpClusterID(req), nil)
+		return r.ClusterAccess.ReconcileDelete(ctx, req)
+	}
+
+	res, err := r.ClusterAccess.Reconcile(ctx, req)
+	if err != nil || res.RequeueAfter > 0 {
+		return res, err
+	}
+	mcp, err := r.ClusterAccess.Access(ctx, req, mcpAccessID)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to get access to MCP cluster: %w", err)
+	}
+	// the ID of the access is the same for all MCP clusters
+	mcp.InitializeID(mcpClusterID(req))
+
+	// ensure QuotaIncrease CRD in MCP cluster
+	if r.Quota.auditing() {
+		log.Debug("Audit mode, skipping creation of CRDs in MCP cluster")
+	} else {
+		crdManager := crdutil.NewCRDManager(openapiconst.ClusterLabel, crds.CRDs)
+		crdManager.AddCRDLabelToClusterMapping(clustersv1alpha1.PURPOSE_ONBOARDING, mcp)
+		crdManager.SkipCRDsWithClusterLabel(clustersv1alpha1.PURPOSE_PLATFORM)
+		if err := crdManager.CreateOrUpdateCRDs(ctx, nil); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error creating/updating CRDs in MCP cluster: %w", err)
+		}
+	}
+
+	if err := r.reconcileNamespaces(ctx, mcp, qdefs); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, nil
+}
+
+// mcpClusterID returns the ID of the MCP cluster belonging to the given reconcile request for the openMCP Cluster resource.
+func mcpClusterID(req reconcile.Request) string {
+	return req.String()
+}
+
+// quotaDefinitionsForCluster returns copies of all quota definitions from the given config which target MCP clusters and whose cluster selector matches the given Cluster.
+// The order of the quota definitions is preserved. Clusters without the 'mcp' purpose never match.
+// The selectors are taken from the given cache, if possible.
+func quotaDefinitionsForCluster(selectors *selectorCache, cfg *quotav1alpha1.QuotaServiceConfig, c *clustersv1alpha1.Cluster) ([]*quotav1alpha1.QuotaDefinition, error) {
+	if !slices.Contains(c.Spec.Purposes, clustersv1alpha1.PURPOSE_MCP) {
+		return nil, nil
+	}
+	res := []*quotav1alpha1.QuotaDefinition{}
+	for _, qd := range cfg.Spec.Quotas {
+		if qd.GetTarget() != quotav1alpha1.TARGET_MCP {
+			continue
+		}
+		sel, err := selectors.clusterSelector(qd)
+		if err != nil {
+			return nil, err
+		}
+		if !sel.Matches(labels.Set(c.Labels)) {
+			continue
+		}
+		res = append(res, qd.DeepCopy())
+	}
+	return res, nil
+}
+
+// reconcileNamespaces applies the first matching of the given quota definitions to each namespace in the MCP cluster.
+// Errors for single namespaces do not abort the reconciliation of the remaining namespaces.
+func (r *MCPQuotaController) reconcileNamespaces(ctx context.Context, mcp *clusters.Cluster, qdefs []*quotav1alpha1.QuotaDefinition) error {
+	log := logging.FromContextOrPanic(ctx)
+
+	nsList := &corev1.NamespaceList{}
+	if err := mcp.Client().List(ctx, nsList); err != nil {
+		return fmt.Errorf("error listing namespaces in MCP cluster: %w", err)
+	}
+
+	selectors := newSelectorCache()
+	selectors.add(qdefs)
+	var errs error
+	// namespaces which have been deleted in the meantime are not reconciled anymore
+	existing := sets.New[string]()
+	for _, ns := range nsList.Items {
+		existing.Insert(ns.Name)
+	}
+	r.Quota.Configs.ForgetClusterDrift(mcp.ID(), existing)
+	for _, ns := range nsList.Items {
+		if ctrlutils.HasAnnotationWithValue(&ns, openapiconst.OperationAnnotation, openapiconst.OperationAnnotationValueIgnore) || ctrlutils.HasAnnotationWithValue(&ns, quotav1alpha1.QuotaOperationLabel, openapiconst.OperationAnnotationValueIgnore) {
+			continue
+		}
+		nsLog := log.WithValues("namespace", ns.Name)
+		nsCtx := logging.NewContext(ctx, nsLog)
+		qdef, err := matchQuotaDefinition(selectors, qdefs, &ns, nil)
+		if err != nil {
+			errs = errors.Join(errs, NewConfigError(fmt.Errorf("error matching quota definitions for namespace '%s': %w", ns.Name, err)))
+			continue
+		}
+		if qdef == nil {
+			nsLog.Debug("No matching quota definition found for namespace, skipping reconciliation")
+			r.Quota.forgetAuditChanges(mcp, ns.Name)
+			r.Quota.forgetDrift(mcp, ns.Name)
+			continue
+		}
+		if err := r.Quota.reconcileNamespace(nsCtx, mcp, &ns, qdef); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("error reconciling namespace '%s': %w", ns.Name, err))
+		}
+	}
+	return errs
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// The Clusters are watched on the platform cluster, a new active QuotaServiceConfig triggers a reconciliation of the affected MCP clusters.
+func (r *MCPQuotaController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(MCPControllerName).
+		WatchesRawSource(source.Kind(r.Quota.PlatformCluster.Cluster().GetCache(), &clustersv1alpha1.Cluster{}, &handler.TypedEnqueueRequestForObject[*clustersv1alpha1.Cluster]{}, predicate.TypedGenerationChangedPredicate[*clustersv1alpha1.Cluster]{})).
+		WatchesRawSource(source.Channel(r.Quota.Configs.Subscribe(), r.configChangeHandler())).
+		WithOptions(r.Quota.controllerOptions()).
+		Complete(r)
+}
+
+// configChangeHandler returns the handler for new active configs from the ConfigWatcher.
+// Like the QuotaController, it only enqueues the MCP clusters for which the set of matching quota definitions changed.
+func (r *MCPQuotaController) configChangeHandler() handler.TypedEventHandler[*quotav1alpha1.QuotaServiceConfig, reconcile.Request] {
+	// the handler is called sequentially by the channel source, so no locking is required
+	var previous *quotav1alpha1.QuotaServiceConfig
+	return handler.TypedFuncs[*quotav1alpha1.QuotaServiceConfig, reconcile.Request]{
+		GenericFunc: func(ctx context.Context, e event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			log := logging.FromContextOrDiscard(ctx).WithName(MCPControllerName)
+			cList := &clustersv1alpha1.ClusterList{}
+			if err := r.Quota.PlatformCluster.Client().List(ctx, cList); err != nil {
+				log.Error(err, "Error listing Clusters for QuotaServiceConfig change")
+				return
+			}
+			reqs := make([]reconcile.Request, 0, len(cList.Items))
+			selectors := newSelectorCache(previous, e.Object)
+			for _, c := range cList.Items {
+				if !slices.Contains(c.Spec.Purposes, clustersv1alpha1.PURPOSE_MCP) {
+					continue
+				}
+				if previous != nil {
+					oldQdefs, oldErr := quotaDefinitionsForCluster(selectors, previous, &c)
+					newQdefs, newErr := quotaDefinitionsForCluster(selectors, e.Object, &c)
+					if oldErr == nil && newErr == nil && equality.Semantic.DeepEqual(oldQdefs, newQdefs) {
+						continue
+					}
+				}
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&c)})
+			}
+			previous = e.Object
+			log.Info("Enqueuing MCP clusters affected by QuotaServiceConfig change", "generation", e.Object.Generation, "affectedClusters", len(reqs), "rate", r.Quota.ConfigRolloutRate)
+			enqueueWithRate(q, reqs, r.Quota.ConfigRolloutRate)
+		},
+	}
+}
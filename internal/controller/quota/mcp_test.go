@@ -0,0 +1,131 @@
+package quota_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openmcp-project/controller-utils/pkg/clusters"
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+	clustersv1alpha1 "github.com/openmcp-project/openmcp-operator/api/clusters/v1alpha1"
+	commonapi "github.com/openmcp-project/openmcp-operator/api/common"
+	"github.com/openmcp-project/openmcp-operator/lib/clusteraccess/advanced"
+
+	quotainstall "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const (
+	mcpRec            = "mcp"
+	providerNamespace = "openmcp-system"
+)
+
+// mcpTestSetup works like defaultTestSetup, but the reconciler is an MCPQuotaController.
+// The returned client is the fake client for the MCP cluster(s), which is returned for any granted AccessRequest.
+func mcpTestSetup(testDataPathSegments ...string) (*testutils.ComplexEnvironment, client.Client) {
+	mcpClient, err := testutils.GetFakeClient(quotainstall.InstallOperatorAPIsMCP(runtime.NewScheme()))
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	objs, err := testutils.LoadObjects(filepath.Join(append(testDataPathSegments, "mcp")...), mcpClient.Scheme())
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	for _, obj := range objs {
+		ExpectWithOffset(1, mcpClient.Create(context.Background(), obj)).To(Succeed())
+	}
+
+	env := testutils.NewComplexEnvironmentBuilder().
+		WithInitObjectPath(platformCluster, filepath.Join(testDataPathSegments...), "platform").
+		WithFakeClient(platformCluster, quotainstall.InstallOperatorAPIsPlatform(runtime.NewScheme())).
+		WithFakeClientBuilderCall(platformCluster, "WithStatusSubresource", &quotav1alpha1.QuotaServiceConfig{}).
+		WithReconcilerConstructor(mcpRec, func(c ...client.Client) reconcile.Reconciler {
+			qc := quotacontroller.NewQuotaController(clusters.NewTestClusterFromClient(platformCluster, c[0]), nil, providerName)
+			mqc := quotacontroller.NewMCPQuotaController(qc, providerNamespace, time.Minute)
+			mqc.ClusterAccess.
+				WithFakingCallback(advanced.FakingCallback_WaitingForAccessRequestReadiness, func(ctx context.Context, platformClusterClient client.Client, _ string, _ *reconcile.Request, _ *clustersv1alpha1.ClusterRequest, ar *clustersv1alpha1.AccessRequest, _ *clustersv1alpha1.Cluster, _ *clusters.Cluster) error {
+					s := &corev1.Secret{}
+					s.SetName(ar.Name)
+					s.SetNamespace(ar.Namespace)
+					s.Data = map[string][]byte{clustersv1alpha1.SecretKeyKubeconfig: []byte("fake")}
+					if err := platformClusterClient.Create(ctx, s); err != nil {
+						return err
+					}
+					ar.Status.Phase = clustersv1alpha1.REQUEST_GRANTED
+					ar.Status.SecretRef = &commonapi.LocalObjectReference{Name: s.Name}
+					return platformClusterClient.Update(ctx, ar)
+				}).
+				WithFakeClientGenerator(func(_ context.Context, _ []byte, _ *runtime.Scheme, _ ...any) (client.Client, error) {
+					return mcpClient, nil
+				})
+			return mqc
+		}, platformCluster).
+		Build()
+
+	registerConfigWatcher(env, env.Reconciler(mcpRec).(*quotacontroller.MCPQuotaController).Quota)
+	cfg := &quotav1alpha1.QuotaServiceConfig{}
+	cfg.SetName(providerName)
+	env.ShouldReconcile(cfgRec, testutils.RequestFromObject(cfg))
+
+	return env, mcpClient
+}
+
+var _ = Describe("MCP Quota Controller", func() {
+
+	It("should apply quota definitions targeting MCP clusters to namespaces within matching MCP clusters", func() {
+		env, mcpClient := mcpTestSetup("testdata", "test-03")
+
+		c := &clustersv1alpha1.Cluster{}
+		c.SetName("mcp-dev")
+		c.SetNamespace("mcp--dev")
+
+		// first reconcile creates the AccessRequest, which is granted by the faking callback
+		res := env.ShouldReconcile(mcpRec, testutils.RequestFromObject(c))
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		ars := &clustersv1alpha1.AccessRequestList{}
+		Expect(env.Client(platformCluster).List(env.Ctx, ars, client.InNamespace(providerNamespace))).To(Succeed())
+		Expect(ars.Items).To(HaveLen(1))
+
+		// second reconcile uses the access to reconcile the namespaces in the MCP cluster
+		res = env.ShouldReconcile(mcpRec, testutils.RequestFromObject(c))
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+
+		ns := &corev1.Namespace{}
+		ns.SetName("ns-tenant")
+		Expect(mcpClient.Get(env.Ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+		Expect(ns.Labels).To(HaveKeyWithValue(quotav1alpha1.BaseQuotaLabel, "mcp-dev"))
+		rq := &corev1.ResourceQuota{}
+		rq.SetName("mcp-dev")
+		rq.SetNamespace(ns.Name)
+		Expect(mcpClient.Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+		Expect(rq.Spec.Hard["count/configmaps"]).To(matchNumericQuantity(5))
+		qi := &quotav1alpha1.QuotaIncrease{}
+		qi.SetName("qi-tenant")
+		qi.SetNamespace(ns.Name)
+		Expect(mcpClient.Get(env.Ctx, client.ObjectKeyFromObject(qi), qi)).To(Succeed())
+		Expect(qi.Annotations).To(HaveKeyWithValue(quotav1alpha1.EffectAnnotation, "count/configmaps: 2"))
+	})
+
+	It("should not request access to MCP clusters which are not matched by any quota definition", func() {
+		env, mcpClient := mcpTestSetup("testdata", "test-03")
+
+		c := &clustersv1alpha1.Cluster{}
+		c.SetName("mcp-prod")
+		c.SetNamespace("mcp--prod")
+
+		res := env.ShouldReconcile(mcpRec, testutils.RequestFromObject(c))
+		Expect(res.RequeueAfter).To(BeZero())
+		ars := &clustersv1alpha1.AccessRequestList{}
+		Expect(env.Client(platformCluster).List(env.Ctx, ars)).To(Succeed())
+		Expect(ars.Items).To(BeEmpty())
+
+		rql := &corev1.ResourceQuotaList{}
+		Expect(mcpClient.List(env.Ctx, rql)).To(Succeed())
+		Expect(rql.Items).To(BeEmpty())
+	})
+
+})
@@ -0,0 +1,50 @@
+package quota
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// resourceQuotaDriftTotal counts how often a generated ResourceQuota was found to deviate from its desired state.
+	resourceQuotaDriftTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quota_resourcequota_drift_total",
+		Help: "Number of times a generated ResourceQuota was found to deviate from its desired state.",
+	}, []string{"quota_definition", "policy"})
+
+	// quotaIncreaseWritesTotal counts the computed metadata updates of QuotaIncreases, split by whether they were written or skipped because the QuotaIncrease was up-to-date.
+	// The skipped ones are the writes saved.
+	quotaIncreaseWritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quota_quotaincrease_writes_total",
+		Help: "Number of metadata updates of QuotaIncreases, by result. Updates with result 'skipped' did not require a write, because the QuotaIncrease was up-to-date.",
+	}, []string{"result"})
+
+	// reconcileErrorsTotal counts the failed reconciles per controller and error class.
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quota_reconcile_errors_total",
+		Help: "Number of failed reconciles, by controller and error class (transient, permanent or config).",
+	}, []string{"controller", "class"})
+
+	// resourceQuotaAdoptionsTotal counts the pre-existing, unmanaged ResourceQuotas which have been taken over, converted or deleted according to the adoption policy.
+	resourceQuotaAdoptionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quota_resourcequota_adoptions_total",
+		Help: "Number of pre-existing ResourceQuotas handled according to the adoption policy of the quota definition, by action (adopted, converted or deleted).",
+	}, []string{"quota_definition", "policy", "action"})
+
+	// auditPendingChanges is the number of changes which the controller would perform, if it did not run in audit mode.
+	auditPendingChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "quota_audit_pending_changes",
+		Help: "Number of changes which have been skipped in audit mode and are still pending, by kind and action.",
+	}, []string{"kind", "action"})
+)
+
+const (
+	// quotaIncreaseWritePatched is the result label value for QuotaIncrease updates which have been written.
+	quotaIncreaseWritePatched = "patched"
+	// quotaIncreaseWriteSkipped is the result label value for QuotaIncrease updates which have been skipped.
+	quotaIncreaseWriteSkipped = "skipped"
+)
+
+func init() {
+	metrics.Registry.MustRegister(resourceQuotaDriftTotal, quotaIncreaseWritesTotal, reconcileErrorsTotal, resourceQuotaAdoptionsTotal, auditPendingChanges)
+}
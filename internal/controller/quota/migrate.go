@@ -0,0 +1,244 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// MigrationQuotaIncreaseName is the name of the QuotaIncreases generated by Migrate.
+// If a QuotaIncrease with this name already exists in a namespace, a numeric suffix is appended.
+const MigrationQuotaIncreaseName = "migration"
+
+// NamespaceMigration describes the QuotaIncrease which preserves the limits of the unmanaged ResourceQuotas of a namespace after the switch to the quota definition.
+type NamespaceMigration struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// QuotaDefinition is the name of the quota definition which would be applied to the namespace.
+	QuotaDefinition string `json:"quotaDefinition"`
+	// Mode is the operating mode of the quota definition.
+	Mode quotav1alpha1.QuotaIncreaseOperatingMode `json:"mode"`
+	// ResourceQuotas are the names of the unmanaged ResourceQuotas whose limits are preserved, sorted by name.
+	ResourceQuotas []string `json:"resourceQuotas"`
+	// SkippedResourceQuotas are the names of the unmanaged ResourceQuotas which have scopes and can't be represented by a QuotaIncrease, sorted by name.
+	SkippedResourceQuotas []string `json:"skippedResourceQuotas,omitempty"`
+	// Current are the hard limits enforced by the unmanaged ResourceQuotas. If multiple ResourceQuotas limit the same resource, the lowest limit is the effective one.
+	Current corev1.ResourceList `json:"current"`
+	// Generated are the hard limits of the ResourceQuota which would be generated with the existing QuotaIncreases.
+	Generated corev1.ResourceList `json:"generated"`
+	// QuotaIncrease is the QuotaIncrease which lifts the generated hard limits to the current ones.
+	// Nil if the generated hard limits are not below the current ones.
+	QuotaIncrease *quotav1alpha1.QuotaIncrease `json:"quotaIncrease,omitempty"`
+	// UseQuotaIncrease is true if the namespace has to reference the QuotaIncrease via the 'quota.openmcp.cloud/use' label, which is the case in singular mode.
+	UseQuotaIncrease bool `json:"useQuotaIncrease,omitempty"`
+	// Rejected contains the reason why the QuotaIncrease would be rejected due to the limits of the quota definition.
+	// Empty if the QuotaIncrease would be accepted.
+	Rejected string `json:"rejected,omitempty"`
+}
+
+// Migrate computes, for each namespace with unmanaged ResourceQuotas, a QuotaIncrease which preserves the current limits once the given config is applied.
+// Only limits which are higher than the ones of the generated ResourceQuota are taken into account, resources which are not limited by the quota definition stay unlimited.
+// The QuotaIncrease depends on the operating mode of the quota definition:
+// in cumulative mode, it contains the difference between the current and the generated limits,
+// in maximum mode, it contains the current limits,
+// in singular mode, it contains the current limits and the ones of the QuotaIncrease referenced by the namespace, which it replaces.
+// The namespaces, QuotaIncreases and ResourceQuotas are expected to be the live objects from the onboarding cluster.
+// Namespaces which are ignored by the QuotaController or managed by another instance of it are skipped, as are namespaces without unmanaged ResourceQuotas.
+// The result is sorted by namespace name. The context is expected to contain a logger.
+func Migrate(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig, providerName string, namespaces []*corev1.Namespace, qis []*quotav1alpha1.QuotaIncrease, rqs []*corev1.ResourceQuota) ([]NamespaceMigration, error) {
+	if err := cfg.Spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid QuotaServiceConfig '%s': %w", cfg.Name, err)
+	}
+	r := &QuotaController{
+		ProviderName: providerName,
+		Configs:      &ConfigWatcher{ProviderName: providerName, active: cfg, selectors: newSelectorCache(cfg)},
+	}
+
+	unmanaged := map[string][]*corev1.ResourceQuota{}
+	for _, rq := range rqs {
+		if isUnmanagedResourceQuota(rq) {
+			unmanaged[rq.Namespace] = append(unmanaged[rq.Namespace], rq)
+		}
+	}
+	qisPerNamespace := map[string]*quotav1alpha1.QuotaIncreaseList{}
+	for _, qi := range qis {
+		if _, ok := qisPerNamespace[qi.Namespace]; !ok {
+			qisPerNamespace[qi.Namespace] = &quotav1alpha1.QuotaIncreaseList{}
+		}
+		qisPerNamespace[qi.Namespace].Items = append(qisPerNamespace[qi.Namespace].Items, *qi)
+	}
+
+	res := []NamespaceMigration{}
+	for _, ns := range reconciledNamespaces(namespaces, providerName) {
+		if len(unmanaged[ns.Name]) == 0 {
+			continue
+		}
+		qdef, err := r.findQuotaDefinition(ns, onboardingTarget)
+		if err != nil {
+			return nil, err
+		}
+		if qdef == nil {
+			continue
+		}
+		nsQis, ok := qisPerNamespace[ns.Name]
+		if !ok {
+			nsQis = &quotav1alpha1.QuotaIncreaseList{}
+		}
+		slices.SortFunc(nsQis.Items, func(a, b quotav1alpha1.QuotaIncrease) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		m := NamespaceMigration{
+			Namespace:       ns.Name,
+			QuotaDefinition: qdef.Name,
+			Mode:            qdef.Mode,
+		}
+		preserved := []*corev1.ResourceQuota{}
+		for _, rq := range unmanaged[ns.Name] {
+			if len(rq.Spec.Scopes) > 0 || rq.Spec.ScopeSelector != nil {
+				m.SkippedResourceQuotas = append(m.SkippedResourceQuotas, rq.Name)
+				continue
+			}
+			m.ResourceQuotas = append(m.ResourceQuotas, rq.Name)
+			preserved = append(preserved, rq)
+		}
+		slices.Sort(m.ResourceQuotas)
+		slices.Sort(m.SkippedResourceQuotas)
+
+		m.Current = effectiveHardLimits(preserved)
+		p := r.preserveHardLimits(ctx, ns, qdef, nsQis, uniqueQuotaIncreaseName(nsQis, MigrationQuotaIncreaseName), m.ResourceQuotas, m.Current)
+		m.Generated = p.generated
+		m.QuotaIncrease = p.quotaIncrease
+		m.UseQuotaIncrease = p.useQuotaIncrease
+		m.Rejected = p.rejected
+		res = append(res, m)
+	}
+	slices.SortFunc(res, func(a, b NamespaceMigration) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return res, nil
+}
+
+// effectiveHardLimits returns the hard limits which are enforced by the given ResourceQuotas together.
+// Pods have to satisfy all ResourceQuotas of their namespace, so if multiple ResourceQuotas limit the same resource, the lowest limit is the effective one.
+func effectiveHardLimits(rqs []*corev1.ResourceQuota) corev1.ResourceList {
+	res := corev1.ResourceList{}
+	for _, rq := range rqs {
+		for rn, q := range rq.Spec.Hard {
+			if cur, ok := res[rn]; !ok || q.Cmp(cur) < 0 {
+				res[rn] = q
+			}
+		}
+	}
+	return res
+}
+
+// uniqueQuotaIncreaseName returns the given name, with a numeric suffix appended if a QuotaIncrease with this name already exists in the list.
+func uniqueQuotaIncreaseName(qis *quotav1alpha1.QuotaIncreaseList, name string) string {
+	existing := sets.New[string]()
+	for _, qi := range qis.Items {
+		existing.Insert(qi.Name)
+	}
+	res := name
+	for i := 1; existing.Has(res); i++ {
+		res = fmt.Sprintf("%s-%d", name, i)
+	}
+	return res
+}
+
+// hardLimitsPreservation is the result of preserveHardLimits.
+type hardLimitsPreservation struct {
+	// generated are the hard limits of the ResourceQuota which would be generated with the existing QuotaIncreases.
+	generated corev1.ResourceList
+	// quotaIncrease lifts the generated hard limits to the current ones. Nil if the generated hard limits are not below the current ones.
+	quotaIncrease *quotav1alpha1.QuotaIncrease
+	// useQuotaIncrease is true if the namespace has to reference the QuotaIncrease via the 'quota.openmcp.cloud/use' label, which is the case in singular mode.
+	useQuotaIncrease bool
+	// rejected contains the reason why the QuotaIncrease would be rejected due to the limits of the quota definition. Empty if it would be accepted.
+	rejected string
+}
+
+// preserveHardLimits computes the QuotaIncrease with the given name which lifts the hard limits generated for the namespace to the given current ones, once the given QuotaIncreases are taken into account.
+// Only limits which are higher than the generated ones are taken into account, resources which are not limited by the quota definition stay unlimited.
+// The QuotaIncrease depends on the operating mode of the quota definition:
+// in cumulative mode, it contains the difference between the current and the generated limits,
+// in maximum mode, it contains the current limits,
+// in singular mode, it contains the current limits and the ones of the QuotaIncrease referenced by the namespace, which it replaces.
+// The QuotaIncrease is annotated with the names of the ResourceQuotas whose limits it preserves.
+func (r *QuotaController) preserveHardLimits(ctx context.Context, ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, nsQis *quotav1alpha1.QuotaIncreaseList, name string, adoptedFrom []string, current corev1.ResourceList) *hardLimitsPreservation {
+	acceptedQis, _ := r.applyQuotaIncreaseLimits(ctx, ns, qdef, nsQis)
+	generated, _ := r.computeResourceQuota(ctx, ns, qdef, acceptedQis)
+	res := &hardLimitsPreservation{generated: generated.Spec.Hard}
+
+	missing := corev1.ResourceList{}
+	for rn, genQ := range res.generated {
+		if curQ, ok := current[rn]; ok && curQ.Cmp(genQ) > 0 {
+			missing[rn] = curQ
+		}
+	}
+	if len(missing) == 0 {
+		return res
+	}
+	res.quotaIncrease = preservingQuotaIncrease(ns, qdef, nsQis, name, adoptedFrom, res.generated, missing)
+	res.useQuotaIncrease = qdef.Mode == quotav1alpha1.SINGULAR
+
+	// check whether the limits of the quota definition would allow the QuotaIncrease
+	// it is the newest QuotaIncrease, so it doesn't take precedence over the existing ones
+	withNew := nsQis.DeepCopy()
+	newQi := res.quotaIncrease.DeepCopy()
+	newQi.CreationTimestamp = metav1.Now()
+	withNew.Items = append(withNew.Items, *newQi)
+	referencingNs := ns
+	if res.useQuotaIncrease {
+		referencingNs = ns.DeepCopy()
+		if referencingNs.Labels == nil {
+			referencingNs.Labels = map[string]string{}
+		}
+		referencingNs.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = res.quotaIncrease.Name
+	}
+	_, rejections := r.applyQuotaIncreaseLimits(ctx, referencingNs, qdef, withNew)
+	res.rejected = rejections[res.quotaIncrease.Name]
+	return res
+}
+
+// preservingQuotaIncrease returns the QuotaIncrease which lifts the generated hard limits to the given missing ones, according to the operating mode.
+func preservingQuotaIncrease(ns *corev1.Namespace, qdef *quotav1alpha1.QuotaDefinition, nsQis *quotav1alpha1.QuotaIncreaseList, name string, adoptedFrom []string, generated, missing corev1.ResourceList) *quotav1alpha1.QuotaIncrease {
+	qi := &quotav1alpha1.QuotaIncrease{}
+	qi.SetGroupVersionKind(quotav1alpha1.GroupVersion.WithKind("QuotaIncrease"))
+	qi.SetName(name)
+	qi.SetNamespace(ns.Name)
+	qi.SetAnnotations(map[string]string{quotav1alpha1.AdoptedFromAnnotation: strings.Join(adoptedFrom, ",")})
+	qi.Spec.Hard = corev1.ResourceList{}
+	switch qdef.Mode {
+	case quotav1alpha1.CUMULATIVE:
+		for rn, q := range missing {
+			diff := q.DeepCopy()
+			diff.Sub(generated[rn])
+			qi.Spec.Hard[rn] = diff
+		}
+	case quotav1alpha1.MAXIMUM:
+		qi.Spec.Hard = missing.DeepCopy()
+	case quotav1alpha1.SINGULAR:
+		// the new QuotaIncrease replaces the referenced one, so it has to contain its limits as well
+		if qiName, ok := ctrlutils.GetLabel(ns, quotav1alpha1.SingularQuotaIncreaseLabel); ok {
+			for _, active := range nsQis.Items {
+				if active.Name == qiName {
+					qi.Spec.Hard = active.Spec.Hard.DeepCopy()
+				}
+			}
+		}
+		for rn, q := range missing {
+			qi.Spec.Hard[rn] = q
+		}
+	}
+	return qi
+}
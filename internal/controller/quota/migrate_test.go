@@ -0,0 +1,154 @@
+package quota_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Migration", func() {
+
+	ctx := logging.NewContextWithDiscard(context.Background())
+
+	unmanagedResourceQuota := func(namespace, name string, hard corev1.ResourceList) *corev1.ResourceQuota {
+		rq := &corev1.ResourceQuota{}
+		rq.SetName(name)
+		rq.SetNamespace(namespace)
+		rq.Spec.Hard = hard
+		return rq
+	}
+
+	// findMigration returns the migration for the namespace with the given name, or nil.
+	findMigration := func(migrations []quotacontroller.NamespaceMigration, namespace string) *quotacontroller.NamespaceMigration {
+		for i := range migrations {
+			if migrations[i].Namespace == namespace {
+				return &migrations[i]
+			}
+		}
+		return nil
+	}
+
+	DescribeTable("should generate QuotaIncreases which preserve the current limits",
+		func(mode quotav1alpha1.QuotaIncreaseOperatingMode, expected corev1.ResourceList) {
+			cfg, namespaces, qis := loadSimulationInput(mode, "testdata", "test-01")
+			rqs := []*corev1.ResourceQuota{
+				unmanagedResourceQuota("ns-normal", "legacy", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("10"), "pods": resource.MustParse("5")}),
+				// the lower limit is the effective one
+				unmanagedResourceQuota("ns-normal", "legacy-2", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("8")}),
+			}
+
+			migrations, err := quotacontroller.Migrate(ctx, cfg, providerName, namespaces, qis, rqs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(migrations).To(HaveLen(1))
+			m := migrations[0]
+			Expect(m.Namespace).To(Equal("ns-normal"))
+			Expect(m.QuotaDefinition).To(Equal("all"))
+			Expect(m.ResourceQuotas).To(Equal([]string{"legacy", "legacy-2"}))
+			Expect(m.Current).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("8")))
+			Expect(m.Generated).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+			Expect(m.QuotaIncrease).ToNot(BeNil())
+			Expect(m.QuotaIncrease.Name).To(Equal(quotacontroller.MigrationQuotaIncreaseName))
+			Expect(m.QuotaIncrease.Annotations).To(HaveKeyWithValue(quotav1alpha1.AdoptedFromAnnotation, "legacy,legacy-2"))
+			// resources which are not limited by the quota definition are not added
+			Expect(m.QuotaIncrease.Spec.Hard).To(HaveLen(len(expected)))
+			for rn, q := range expected {
+				Expect(m.QuotaIncrease.Spec.Hard).To(HaveKeyWithValue(rn, matchQuantity(q)))
+			}
+			Expect(m.UseQuotaIncrease).To(Equal(mode == quotav1alpha1.SINGULAR))
+			Expect(m.Rejected).To(BeEmpty())
+
+			// with the QuotaIncrease, the generated ResourceQuota has the current limits
+			for _, ns := range namespaces {
+				if ns.Name == m.Namespace && m.UseQuotaIncrease {
+					if ns.Labels == nil {
+						ns.Labels = map[string]string{}
+					}
+					ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel] = m.QuotaIncrease.Name
+				}
+			}
+			sims, err := quotacontroller.Simulate(ctx, cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, namespaces, append(qis, m.QuotaIncrease))
+			Expect(err).ToNot(HaveOccurred())
+			for _, sim := range sims {
+				if sim.Namespace == m.Namespace {
+					Expect(sim.ResourceQuota.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), matchNumericQuantity(8)))
+				}
+			}
+		},
+		Entry("cumulative mode adds the difference", quotav1alpha1.CUMULATIVE, corev1.ResourceList{"count/serviceaccounts": resource.MustParse("5")}),
+		Entry("maximum mode uses the current limits", quotav1alpha1.MAXIMUM, corev1.ResourceList{"count/serviceaccounts": resource.MustParse("8")}),
+		Entry("singular mode uses the current limits", quotav1alpha1.SINGULAR, corev1.ResourceList{"count/serviceaccounts": resource.MustParse("8")}),
+	)
+
+	It("should take the existing QuotaIncreases into account", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-01")
+		rqs := []*corev1.ResourceQuota{
+			unmanagedResourceQuota("ns-project", "legacy", corev1.ResourceList{"count/secrets": resource.MustParse("100")}),
+		}
+		sims, err := quotacontroller.Simulate(ctx, cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, namespaces, qis)
+		Expect(err).ToNot(HaveOccurred())
+		var generated resource.Quantity
+		for _, sim := range sims {
+			if sim.Namespace == "ns-project" {
+				generated = sim.ResourceQuota.Spec.Hard["count/secrets"]
+			}
+		}
+
+		migrations, err := quotacontroller.Migrate(ctx, cfg, providerName, namespaces, qis, rqs)
+		Expect(err).ToNot(HaveOccurred())
+		m := findMigration(migrations, "ns-project")
+		Expect(m).ToNot(BeNil())
+		Expect(m.Generated).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), generated))
+		expected := resource.MustParse("100")
+		expected.Sub(generated)
+		Expect(m.QuotaIncrease.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchQuantity(expected)))
+	})
+
+	It("should skip managed and scoped ResourceQuotas and namespaces whose limits are preserved already", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-01")
+		managed := unmanagedResourceQuota("ns-normal", "all", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("100")})
+		managed.SetLabels(map[string]string{quotav1alpha1.ManagedByLabel: providerName})
+		scoped := unmanagedResourceQuota("ns-normal", "scoped", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("100")})
+		scoped.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+		rqs := []*corev1.ResourceQuota{
+			managed,
+			scoped,
+			unmanagedResourceQuota("ns-normal", "low", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("2")}),
+		}
+
+		migrations, err := quotacontroller.Migrate(ctx, cfg, providerName, namespaces, qis, rqs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(1))
+		Expect(migrations[0].ResourceQuotas).To(Equal([]string{"low"}))
+		Expect(migrations[0].SkippedResourceQuotas).To(Equal([]string{"scoped"}))
+		Expect(migrations[0].QuotaIncrease).To(BeNil())
+	})
+
+	It("should report QuotaIncreases which would be rejected and avoid name conflicts", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-01")
+		cfg.Spec.GetQuotaDefinitionForName("all").QuotaIncreaseLimits = &quotav1alpha1.QuotaIncreaseLimits{
+			MaxIncrease: corev1.ResourceList{"count/serviceaccounts": resource.MustParse("1")},
+		}
+		existing := &quotav1alpha1.QuotaIncrease{}
+		existing.SetName(quotacontroller.MigrationQuotaIncreaseName)
+		existing.SetNamespace("ns-normal")
+		rqs := []*corev1.ResourceQuota{
+			unmanagedResourceQuota("ns-normal", "legacy", corev1.ResourceList{"count/serviceaccounts": resource.MustParse("10")}),
+		}
+
+		migrations, err := quotacontroller.Migrate(ctx, cfg, providerName, namespaces, append(qis, existing), rqs)
+		Expect(err).ToNot(HaveOccurred())
+		m := findMigration(migrations, "ns-normal")
+		Expect(m).ToNot(BeNil())
+		Expect(m.QuotaIncrease.Name).To(Equal(quotacontroller.MigrationQuotaIncreaseName + "-1"))
+		Expect(m.Rejected).ToNot(BeEmpty())
+	})
+
+})
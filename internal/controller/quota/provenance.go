@@ -0,0 +1,69 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// ResourceQuotaProvenance explains how the hard limits of a generated ResourceQuota have been computed.
+// It is stored as JSON in the provenance annotation of the ResourceQuota.
+type ResourceQuotaProvenance struct {
+	// QuotaDefinition is the name of the quota definition the ResourceQuota is based on.
+	QuotaDefinition string `json:"quotaDefinition"`
+	// Mode is the operating mode of the quota definition.
+	Mode quotav1alpha1.QuotaIncreaseOperatingMode `json:"mode"`
+	// Resources contains the provenance of each hard limit of the ResourceQuota.
+	Resources map[corev1.ResourceName]ResourceProvenance `json:"resources"`
+}
+
+// ResourceProvenance explains how a single hard limit of a generated ResourceQuota has been computed.
+type ResourceProvenance struct {
+	// Hard is the resulting hard limit.
+	Hard resource.Quantity `json:"hard"`
+	// Base is the hard limit from the template of the quota definition. Nil if the template does not limit the resource.
+	Base *resource.Quantity `json:"base,omitempty"`
+	// QuotaIncreases contains the quantities of the QuotaIncreases which contribute to the hard limit, keyed by QuotaIncrease name.
+	// In cumulative mode, they are added to the base value, in maximum and singular mode, the single contributing QuotaIncrease replaces it.
+	QuotaIncreases map[string]resource.Quantity `json:"quotaIncreases,omitempty"`
+}
+
+// resourceQuotaProvenance returns the provenance of the given hard limits, which have been computed from the given base hard limits and the effects of the QuotaIncreases.
+func resourceQuotaProvenance(qdef *quotav1alpha1.QuotaDefinition, base, hard corev1.ResourceList, effects map[string]corev1.ResourceList) *ResourceQuotaProvenance {
+	p := &ResourceQuotaProvenance{
+		QuotaDefinition: qdef.Name,
+		Mode:            qdef.Mode,
+		Resources:       make(map[corev1.ResourceName]ResourceProvenance, len(hard)),
+	}
+	for rn, q := range hard {
+		rp := ResourceProvenance{Hard: q}
+		if baseQ, ok := base[rn]; ok {
+			rp.Base = &baseQ
+		}
+		for qiName, effect := range effects {
+			if qiQ, ok := effect[rn]; ok {
+				if rp.QuotaIncreases == nil {
+					rp.QuotaIncreases = map[string]resource.Quantity{}
+				}
+				rp.QuotaIncreases[qiName] = qiQ
+			}
+		}
+		p.Resources[rn] = rp
+	}
+	return p
+}
+
+// String returns the JSON representation of the provenance, which is used as value of the provenance annotation.
+// The keys are sorted, so the value only changes if the provenance changes.
+func (p *ResourceQuotaProvenance) String() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		// cannot happen, all fields are marshallable
+		return fmt.Sprintf("error marshalling provenance: %v", err)
+	}
+	return string(data)
+}
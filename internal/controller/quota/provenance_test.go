@@ -0,0 +1,84 @@
+package quota_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("ResourceQuota Provenance", func() {
+
+	// provenance reconciles the namespace and returns the parsed provenance annotation of its generated ResourceQuota.
+	provenance := func(env *testutils.ComplexEnvironment, nsName string) *quotacontroller.ResourceQuotaProvenance {
+		ns := &corev1.Namespace{}
+		ns.SetName(nsName)
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		rqs := &corev1.ResourceQuotaList{}
+		ExpectWithOffset(1, env.Client(onboardingCluster).List(env.Ctx, rqs, client.InNamespace(nsName))).To(Succeed())
+		ExpectWithOffset(1, rqs.Items).To(HaveLen(1))
+		ExpectWithOffset(1, rqs.Items[0].Annotations).To(HaveKey(quotav1alpha1.ProvenanceAnnotation))
+		p := &quotacontroller.ResourceQuotaProvenance{}
+		ExpectWithOffset(1, json.Unmarshal([]byte(rqs.Items[0].Annotations[quotav1alpha1.ProvenanceAnnotation]), p)).To(Succeed())
+		return p
+	}
+
+	It("should list all QuotaIncreases which add to a hard limit in cumulative mode", func() {
+		env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		p := provenance(env, "ns-project")
+		Expect(p.QuotaDefinition).To(Equal("project"))
+		Expect(p.Mode).To(Equal(quotav1alpha1.CUMULATIVE))
+		Expect(p.Resources).To(HaveLen(3))
+
+		secrets := p.Resources["count/secrets"]
+		Expect(secrets.Hard).To(matchNumericQuantity(53))
+		Expect(secrets.Base).ToNot(BeNil())
+		Expect(*secrets.Base).To(matchNumericQuantity(3))
+		Expect(secrets.QuotaIncreases).To(HaveLen(3))
+		Expect(secrets.QuotaIncreases).To(HaveKeyWithValue("qi-project-max", matchNumericQuantity(30)))
+		Expect(secrets.QuotaIncreases).To(HaveKeyWithValue("qi-project-med", matchNumericQuantity(10)))
+		Expect(secrets.QuotaIncreases).To(HaveKeyWithValue("qi-project-sa", matchNumericQuantity(10)))
+
+		// resources which are not limited by the template have no base value
+		configmaps := p.Resources["count/configmaps"]
+		Expect(configmaps.Hard).To(matchNumericQuantity(10))
+		Expect(configmaps.Base).To(BeNil())
+		Expect(configmaps.QuotaIncreases).To(HaveLen(1))
+		Expect(configmaps.QuotaIncreases).To(HaveKeyWithValue("qi-project-max", matchNumericQuantity(10)))
+	})
+
+	It("should only list the QuotaIncrease which provides a hard limit in maximum mode", func() {
+		env := defaultTestSetup(quotav1alpha1.MAXIMUM, false, "testdata", "test-01")
+		p := provenance(env, "ns-project")
+		Expect(p.Mode).To(Equal(quotav1alpha1.MAXIMUM))
+
+		secrets := p.Resources["count/secrets"]
+		Expect(secrets.Hard).To(matchNumericQuantity(30))
+		Expect(*secrets.Base).To(matchNumericQuantity(3))
+		Expect(secrets.QuotaIncreases).To(HaveLen(1))
+		Expect(secrets.QuotaIncreases).To(HaveKeyWithValue("qi-project-max", matchNumericQuantity(30)))
+
+		serviceAccounts := p.Resources["count/serviceaccounts"]
+		Expect(serviceAccounts.Hard).To(matchNumericQuantity(5))
+		Expect(serviceAccounts.QuotaIncreases).To(HaveKeyWithValue("qi-project-sa", matchNumericQuantity(5)))
+	})
+
+	It("should only contain the base value for hard limits without QuotaIncreases", func() {
+		env := defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		p := provenance(env, "ns-normal")
+		Expect(p.QuotaDefinition).To(Equal("all"))
+		Expect(p.Resources).To(HaveLen(1))
+		serviceAccounts := p.Resources["count/serviceaccounts"]
+		Expect(serviceAccounts.Hard).To(matchNumericQuantity(3))
+		Expect(*serviceAccounts.Base).To(matchNumericQuantity(3))
+		Expect(serviceAccounts.QuotaIncreases).To(BeEmpty())
+	})
+
+})
@@ -0,0 +1,39 @@
+package quota
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultMaxConcurrentReconciles is the default number of reconciles which run in parallel per controller.
+	DefaultMaxConcurrentReconciles = 1
+	// DefaultBackoffBaseDelay is the default delay before a failed reconcile is retried for the first time.
+	DefaultBackoffBaseDelay = 5 * time.Millisecond
+	// DefaultBackoffMaxDelay is the default maximum delay between retries of a failed reconcile.
+	DefaultBackoffMaxDelay = 1000 * time.Second
+)
+
+// controllerOptions returns the options for the controllers which reconcile namespaces and MCP clusters.
+// The priority queue is always enabled, because bulk reconciles after config changes are enqueued with low priority.
+func (r *QuotaController) controllerOptions() controller.Options {
+	opts := controller.Options{
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		UsePriorityQueue:        ptr.To(true),
+	}
+	if r.BackoffBaseDelay > 0 || r.BackoffMaxDelay > 0 {
+		baseDelay, maxDelay := r.BackoffBaseDelay, r.BackoffMaxDelay
+		if baseDelay <= 0 {
+			baseDelay = DefaultBackoffBaseDelay
+		}
+		if maxDelay <= 0 {
+			maxDelay = DefaultBackoffMaxDelay
+		}
+		opts.RateLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay)
+	}
+	return opts
+}
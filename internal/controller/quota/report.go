@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ctrlutils "github.com/openmcp-project/controller-utils/pkg/controller"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// NamespaceReport is the quota inventory of a single namespace.
+type NamespaceReport struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// QuotaDefinition is the name of the quota definition which is applied to the namespace.
+	QuotaDefinition string `json:"quotaDefinition"`
+	// Resources contains one entry per resource limited by the generated ResourceQuota, sorted by resource name.
+	// Empty if no generated ResourceQuota exists yet.
+	Resources []ResourceReport `json:"resources,omitempty"`
+}
+
+// ResourceReport is the quota inventory of a single resource within a namespace.
+type ResourceReport struct {
+	// Resource is the name of the resource.
+	Resource corev1.ResourceName `json:"resource"`
+	// Hard is the effective hard limit of the generated ResourceQuota.
+	Hard resource.Quantity `json:"hard"`
+	// Increases is the sum of the amounts requested for this resource by all QuotaIncreases in the namespace.
+	// Depending on the operating mode, this can differ from the amount by which the hard limit has actually been increased.
+	Increases resource.Quantity `json:"increases"`
+	// Used is the current usage, as reported in the status of the generated ResourceQuota.
+	Used resource.Quantity `json:"used"`
+	// Utilization is the usage in percent of the hard limit. Nil if the hard limit is zero.
+	Utilization *float64 `json:"utilization,omitempty"`
+}
+
+// Report builds the quota inventory for the given namespaces, which are expected to be the live objects from the onboarding cluster.
+// Only namespaces which are managed by the QuotaController with the given provider name are taken into account. If the provider name is empty, all managed namespaces are taken into account.
+// If qdefNames is not empty, only namespaces with one of the given quota definitions are included.
+// The result is sorted by namespace name.
+func Report(providerName string, qdefNames []string, namespaces []*corev1.Namespace, qis []*quotav1alpha1.QuotaIncrease, rqs []*corev1.ResourceQuota) []NamespaceReport {
+	increases := map[string]corev1.ResourceList{}
+	for _, qi := range qis {
+		if _, ok := increases[qi.Namespace]; !ok {
+			increases[qi.Namespace] = corev1.ResourceList{}
+		}
+		for res, q := range qi.Spec.Hard {
+			sum := increases[qi.Namespace][res]
+			sum.Add(q)
+			increases[qi.Namespace][res] = sum
+		}
+	}
+
+	res := []NamespaceReport{}
+	for _, ns := range namespaces {
+		managedBy, ok := ctrlutils.GetLabel(ns, quotav1alpha1.ManagedByLabel)
+		if !ok || (providerName != "" && managedBy != providerName) {
+			continue
+		}
+		qdefName := ns.Labels[quotav1alpha1.BaseQuotaLabel]
+		if len(qdefNames) > 0 && !slices.Contains(qdefNames, qdefName) {
+			continue
+		}
+		nr := NamespaceReport{
+			Namespace:       ns.Name,
+			QuotaDefinition: qdefName,
+		}
+		if rq := findManagedResourceQuota(rqs, managedBy, ns.Name, qdefName); rq != nil {
+			for _, rn := range sets.List(sets.KeySet(rq.Spec.Hard)) {
+				rr := ResourceReport{
+					Resource:  rn,
+					Hard:      rq.Spec.Hard[rn],
+					Increases: increases[ns.Name][rn],
+					Used:      rq.Status.Used[rn],
+				}
+				if !rr.Hard.IsZero() {
+					utilization := rr.Used.AsApproximateFloat64() / rr.Hard.AsApproximateFloat64() * 100
+					rr.Utilization = &utilization
+				}
+				nr.Resources = append(nr.Resources, rr)
+			}
+		}
+		res = append(res, nr)
+	}
+	slices.SortFunc(res, func(a, b NamespaceReport) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return res
+}
@@ -0,0 +1,100 @@
+package quota_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Report", func() {
+
+	var namespaces []*corev1.Namespace
+	var qis []*quotav1alpha1.QuotaIncrease
+	var rqs []*corev1.ResourceQuota
+
+	BeforeEach(func() {
+		newNamespace := func(name, managedBy, qdefName string) *corev1.Namespace {
+			ns := &corev1.Namespace{}
+			ns.SetName(name)
+			if managedBy != "" {
+				ns.SetLabels(map[string]string{
+					quotav1alpha1.ManagedByLabel: managedBy,
+					quotav1alpha1.BaseQuotaLabel: qdefName,
+				})
+			}
+			return ns
+		}
+		newQuotaIncrease := func(name, namespace string, hard corev1.ResourceList) *quotav1alpha1.QuotaIncrease {
+			qi := &quotav1alpha1.QuotaIncrease{}
+			qi.SetName(name)
+			qi.SetNamespace(namespace)
+			qi.Spec.Hard = hard
+			return qi
+		}
+		namespaces = []*corev1.Namespace{
+			newNamespace("ns-b", providerName, "small"),
+			newNamespace("ns-a", providerName, "large"),
+			newNamespace("ns-foreign", "foreign", "large"),
+			newNamespace("ns-unmanaged", "", ""),
+		}
+		qis = []*quotav1alpha1.QuotaIncrease{
+			newQuotaIncrease("qi-1", "ns-a", corev1.ResourceList{"count/secrets": resource.MustParse("2")}),
+			newQuotaIncrease("qi-2", "ns-a", corev1.ResourceList{"count/secrets": resource.MustParse("3"), "count/configmaps": resource.MustParse("1")}),
+		}
+		rq := &corev1.ResourceQuota{}
+		rq.SetName("large")
+		rq.SetNamespace("ns-a")
+		rq.SetLabels(map[string]string{
+			quotav1alpha1.ManagedByLabel:       providerName,
+			quotav1alpha1.QuotaDefinitionLabel: "large",
+		})
+		rq.Spec.Hard = corev1.ResourceList{"count/secrets": resource.MustParse("20"), "count/configmaps": resource.MustParse("0")}
+		rq.Status.Used = corev1.ResourceList{"count/secrets": resource.MustParse("5")}
+		rqs = []*corev1.ResourceQuota{rq}
+	})
+
+	It("should report hard limits, increases, usage and utilization of all managed namespaces", func() {
+		reports := quotacontroller.Report(providerName, nil, namespaces, qis, rqs)
+		Expect(reports).To(HaveExactElements(
+			MatchAllFields(Fields{
+				"Namespace":       Equal("ns-a"),
+				"QuotaDefinition": Equal("large"),
+				"Resources": HaveExactElements(
+					MatchAllFields(Fields{
+						"Resource":    Equal(corev1.ResourceName("count/configmaps")),
+						"Hard":        matchNumericQuantity(0),
+						"Increases":   matchNumericQuantity(1),
+						"Used":        matchNumericQuantity(0),
+						"Utilization": BeNil(),
+					}),
+					MatchAllFields(Fields{
+						"Resource":    Equal(corev1.ResourceName("count/secrets")),
+						"Hard":        matchNumericQuantity(20),
+						"Increases":   matchNumericQuantity(5),
+						"Used":        matchNumericQuantity(5),
+						"Utilization": PointTo(BeNumerically("~", 25.0)),
+					}),
+				),
+			}),
+			MatchAllFields(Fields{
+				"Namespace":       Equal("ns-b"),
+				"QuotaDefinition": Equal("small"),
+				"Resources":       BeEmpty(),
+			}),
+		))
+	})
+
+	It("should filter by quota definition", func() {
+		reports := quotacontroller.Report("", []string{"large"}, namespaces, qis, rqs)
+		Expect(reports).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{"Namespace": Equal("ns-a")}),
+			MatchFields(IgnoreExtras, Fields{"Namespace": Equal("ns-foreign")}),
+		))
+	})
+
+})
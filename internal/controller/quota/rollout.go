@@ -0,0 +1,92 @@
+package quota
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// DefaultConfigRolloutRate is the default number of namespaces per second which are enqueued after a config change.
+const DefaultConfigRolloutRate = 50
+
+// ConfigChangeHandler returns the handler for new active configs from the ConfigWatcher.
+// It compares the new config with the previously handled one and only enqueues the namespaces whose matching quota definition changed,
+// which includes changes to the template, the mode and all other fields of the quota definition, as well as namespaces which now match a different quota definition.
+// The first config enqueues all namespaces.
+// If ConfigRolloutRate is set, the namespaces are enqueued with increasing delays, so that large rollouts are spread over time.
+func (r *QuotaController) ConfigChangeHandler() handler.TypedEventHandler[*quotav1alpha1.QuotaServiceConfig, reconcile.Request] {
+	// the handler is called sequentially by the channel source, so no locking is required
+	var previous *quotav1alpha1.QuotaServiceConfig
+	return handler.TypedFuncs[*quotav1alpha1.QuotaServiceConfig, reconcile.Request]{
+		GenericFunc: func(ctx context.Context, e event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig], q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			log := logging.FromContextOrDiscard(ctx).WithName(ControllerName)
+			nsList := &corev1.NamespaceList{}
+			if err := r.reader(r.OnboardingCluster).List(ctx, nsList); err != nil {
+				// previous is not updated, so the next config change is compared against the older config and includes the namespaces affected by this change
+				log.Error(err, "Error listing namespaces for QuotaServiceConfig change")
+				return
+			}
+			reqs := make([]reconcile.Request, 0, len(nsList.Items))
+			selectors := newSelectorCache(previous, e.Object)
+			for _, ns := range nsList.Items {
+				if quotaDefinitionChanged(selectors, previous, e.Object, &ns, onboardingTarget) {
+					reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+				}
+			}
+			previous = e.Object
+			log.Info("Enqueuing namespaces affected by QuotaServiceConfig change", "generation", e.Object.Generation, "affectedNamespaces", len(reqs), "totalNamespaces", len(nsList.Items), "rate", r.ConfigRolloutRate)
+			enqueueWithRate(q, reqs, r.ConfigRolloutRate)
+		},
+	}
+}
+
+// quotaDefinitionChanged returns true if the quota definition which matches the namespace differs between the old and the new config.
+// Always returns true if there is no old config. The selectors are taken from the given cache, if possible.
+func quotaDefinitionChanged(selectors *selectorCache, oldCfg, newCfg *quotav1alpha1.QuotaServiceConfig, ns *corev1.Namespace, filter func(qd *quotav1alpha1.QuotaDefinition) bool) bool {
+	if oldCfg == nil {
+		return true
+	}
+	oldQdef, err := matchQuotaDefinition(selectors, oldCfg.Spec.Quotas, ns, filter)
+	if err != nil {
+		return true
+	}
+	newQdef, err := matchQuotaDefinition(selectors, newCfg.Spec.Quotas, ns, filter)
+	if err != nil {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldQdef, newQdef)
+}
+
+// enqueueWithRate adds the requests to the queue, spreading them over time according to the given rate (requests per second).
+// A rate <= 0 adds all requests immediately.
+// If the queue is a priority queue, the requests are added with low priority, so that these bulk reconciles don't delay reconciles triggered by changes to single objects.
+func enqueueWithRate(q workqueue.TypedRateLimitingInterface[reconcile.Request], reqs []reconcile.Request, rate float64) {
+	pq, isPriorityQueue := q.(priorityqueue.PriorityQueue[reconcile.Request])
+	for i, req := range reqs {
+		var after time.Duration
+		if rate > 0 {
+			after = time.Duration(float64(i) / rate * float64(time.Second))
+		}
+		switch {
+		case isPriorityQueue:
+			pq.AddWithOpts(priorityqueue.AddOpts{After: after, Priority: ptr.To(handler.LowPriority)}, req)
+		case after > 0:
+			q.AddAfter(req, after)
+		default:
+			q.Add(req)
+		}
+	}
+}
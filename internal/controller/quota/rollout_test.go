@@ -0,0 +1,113 @@
+package quota_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+var _ = Describe("Config Rollout", func() {
+
+	var env *testutils.ComplexEnvironment
+	var qc *quotacontroller.QuotaController
+	var queue workqueue.TypedRateLimitingInterface[reconcile.Request]
+
+	// drain returns the names of all requests which are currently in the queue.
+	drain := func() []string {
+		names := []string{}
+		for queue.Len() > 0 {
+			req, _ := queue.Get()
+			names = append(names, req.Name)
+			queue.Done(req)
+			queue.Forget(req)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		qc = env.Reconciler(rec).(*quotacontroller.QuotaController)
+		qc.ConfigRolloutRate = 0
+		queue = workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		DeferCleanup(queue.ShutDown)
+	})
+
+	It("should only enqueue namespaces whose matching quota definition changed", func() {
+		h := qc.ConfigChangeHandler()
+
+		// the first config enqueues all namespaces
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(drain()).To(ConsistOf("ns-normal", "ns-project", "ns-workspace"))
+
+		// changing the template of a quota definition only affects the namespaces using it
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("project").ResourceQuotaTemplate.Spec.Hard["count/secrets"] = resource.MustParse("5")
+		})
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(drain()).To(ConsistOf("ns-project"))
+
+		// changing a quota definition which is not used by any namespace does not enqueue anything
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("all2").ResourceQuotaTemplate.Spec.Hard["count/serviceaccounts"] = resource.MustParse("5")
+		})
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(drain()).To(BeEmpty())
+
+		// changing the mode affects the namespaces using the quota definition
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("workspace").Mode = quotav1alpha1.MAXIMUM
+		})
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(drain()).To(ConsistOf("ns-workspace"))
+
+		// namespaces which match a different quota definition are enqueued, as well as the ones using the modified definition
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("project").Selector = nil
+		})
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(drain()).To(ConsistOf("ns-normal", "ns-project", "ns-workspace"))
+	})
+
+	It("should spread the namespaces over time according to the rollout rate", func() {
+		qc.ConfigRolloutRate = 10
+		h := qc.ConfigChangeHandler()
+
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, queue)
+		Expect(queue.Len()).To(Equal(1))
+		Eventually(queue.Len).WithTimeout(time.Second).WithPolling(10 * time.Millisecond).Should(Equal(3))
+	})
+
+	It("should enqueue the namespaces with low priority if the queue supports priorities", func() {
+		pq := priorityqueue.New[reconcile.Request]("test")
+		DeferCleanup(pq.ShutDown)
+		h := qc.ConfigChangeHandler()
+
+		h.Generic(env.Ctx, event.TypedGenericEvent[*quotav1alpha1.QuotaServiceConfig]{Object: qc.Configs.Active()}, pq)
+		// a change to a single namespace is enqueued with the default priority and overtakes the bulk reconciles
+		pq.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: "ns-workspace"}})
+		Eventually(pq.Len).WithTimeout(time.Second).WithPolling(10 * time.Millisecond).Should(Equal(3))
+
+		req, priority, _ := pq.GetWithPriority()
+		Expect(req.Name).To(Equal("ns-workspace"))
+		Expect(priority).To(Equal(0))
+		pq.Done(req)
+		for range 2 {
+			_, priority, _ = pq.GetWithPriority()
+			Expect(priority).To(Equal(handler.LowPriority))
+		}
+	})
+
+})
@@ -0,0 +1,87 @@
+package quota
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// selectorCache holds the compiled label selectors of quota definitions, so that they don't have to be converted for every namespace.
+// It is keyed by the quota definitions' pointers and therefore only valid for configs which are never modified, like the ones provided by the ConfigWatcher.
+// A cache is read-only after it has been built, so it can be shared without locking.
+// Selectors of quota definitions which are not part of the cache are compiled on demand, this also applies to a nil cache.
+type selectorCache struct {
+	namespaceSelectors map[*quotav1alpha1.QuotaDefinition]labels.Selector
+	clusterSelectors   map[*quotav1alpha1.QuotaDefinition]labels.Selector
+}
+
+// newSelectorCache returns a cache containing the selectors of all quota definitions of the given configs.
+// Nil configs are skipped.
+func newSelectorCache(cfgs ...*quotav1alpha1.QuotaServiceConfig) *selectorCache {
+	c := &selectorCache{
+		namespaceSelectors: map[*quotav1alpha1.QuotaDefinition]labels.Selector{},
+		clusterSelectors:   map[*quotav1alpha1.QuotaDefinition]labels.Selector{},
+	}
+	for _, cfg := range cfgs {
+		if cfg != nil {
+			c.add(cfg.Spec.Quotas)
+		}
+	}
+	return c
+}
+
+// add compiles the selectors of the given quota definitions and adds them to the cache.
+// Invalid selectors are not added, so that the error is returned when they are requested.
+// Must not be called after the cache has been shared.
+func (c *selectorCache) add(qdefs []*quotav1alpha1.QuotaDefinition) {
+	for _, qd := range qdefs {
+		if qd == nil {
+			continue
+		}
+		if sel, err := compileSelector(qd.Selector); err == nil {
+			c.namespaceSelectors[qd] = sel
+		}
+		if sel, err := compileSelector(qd.ClusterSelector); err == nil {
+			c.clusterSelectors[qd] = sel
+		}
+	}
+}
+
+// namespaceSelector returns the selector for the namespaces of the given quota definition.
+func (c *selectorCache) namespaceSelector(qd *quotav1alpha1.QuotaDefinition) (labels.Selector, error) {
+	if c != nil {
+		if sel, ok := c.namespaceSelectors[qd]; ok {
+			return sel, nil
+		}
+	}
+	sel, err := compileSelector(qd.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("error converting label selector for quota definition '%s': %w", qd.Name, err)
+	}
+	return sel, nil
+}
+
+// clusterSelector returns the selector for the MCP clusters of the given quota definition.
+func (c *selectorCache) clusterSelector(qd *quotav1alpha1.QuotaDefinition) (labels.Selector, error) {
+	if c != nil {
+		if sel, ok := c.clusterSelectors[qd]; ok {
+			return sel, nil
+		}
+	}
+	sel, err := compileSelector(qd.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("error converting cluster selector for quota definition '%s': %w", qd.Name, err)
+	}
+	return sel, nil
+}
+
+// compileSelector converts the given label selector. In contrast to metav1.LabelSelectorAsSelector, a nil selector matches everything.
+func compileSelector(sel *metav1.LabelSelector) (labels.Selector, error) {
+	if sel == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(sel)
+}
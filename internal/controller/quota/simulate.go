@@ -0,0 +1,123 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+// NamespaceSimulation is the result of simulating the reconciliation of a single namespace.
+type NamespaceSimulation struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// QuotaDefinition is the name of the matching quota definition.
+	// Empty if no quota definition matches the namespace.
+	QuotaDefinition string `json:"quotaDefinition,omitempty"`
+	// ResourceQuota is the ResourceQuota that would be generated for the namespace.
+	ResourceQuota *corev1.ResourceQuota `json:"resourceQuota,omitempty"`
+	// LimitRange is the LimitRange that would be generated for the namespace, if any.
+	LimitRange *corev1.LimitRange `json:"limitRange,omitempty"`
+	// QuotaIncreases contains the outcome for each QuotaIncrease in the namespace, sorted by name.
+	QuotaIncreases []QuotaIncreaseSimulation `json:"quotaIncreases,omitempty"`
+}
+
+// QuotaIncreaseSimulation is the simulated outcome for a single QuotaIncrease.
+type QuotaIncreaseSimulation struct {
+	// Name is the name of the QuotaIncrease.
+	Name string `json:"name"`
+	// Effect is the part of the QuotaIncrease which contributes to the ResourceQuota.
+	Effect corev1.ResourceList `json:"effect,omitempty"`
+	// EffectAnnotation is the value the effect annotation of the QuotaIncrease would have.
+	// Empty if the QuotaIncrease would be deleted.
+	EffectAnnotation string `json:"effectAnnotation,omitempty"`
+	// EffectDetails describes the effect of each requested resource, as it would be stored in the effect details annotation of the QuotaIncrease.
+	// Nil if the QuotaIncrease would be deleted or not be evaluated.
+	EffectDetails *QuotaIncreaseEffect `json:"effectDetails,omitempty"`
+	// Rejected contains the reason why the QuotaIncrease would be rejected due to the limits of the quota definition.
+	// Empty if the QuotaIncrease is not rejected.
+	Rejected string `json:"rejected,omitempty"`
+	// Deleted is true if the QuotaIncrease would be deleted.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Simulate computes the ResourceQuotas, LimitRanges and QuotaIncrease effects which the QuotaController would produce for the given namespaces, without any cluster access.
+// Only quota definitions with the given target are taken into account.
+// The QuotaIncreases are assigned to the namespaces based on their namespace field. The result is sorted by namespace name.
+// The context is expected to contain a logger.
+func Simulate(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig, providerName string, target quotav1alpha1.QuotaTarget, namespaces []*corev1.Namespace, qis []*quotav1alpha1.QuotaIncrease) ([]NamespaceSimulation, error) {
+	if err := cfg.Spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid QuotaServiceConfig '%s': %w", cfg.Name, err)
+	}
+	r := &QuotaController{
+		ProviderName: providerName,
+		Configs:      &ConfigWatcher{ProviderName: providerName, active: cfg, selectors: newSelectorCache(cfg)},
+	}
+
+	qisPerNamespace := map[string]*quotav1alpha1.QuotaIncreaseList{}
+	for _, qi := range qis {
+		if _, ok := qisPerNamespace[qi.Namespace]; !ok {
+			qisPerNamespace[qi.Namespace] = &quotav1alpha1.QuotaIncreaseList{}
+		}
+		qisPerNamespace[qi.Namespace].Items = append(qisPerNamespace[qi.Namespace].Items, *qi)
+	}
+
+	res := make([]NamespaceSimulation, 0, len(namespaces))
+	for _, ns := range namespaces {
+		sim := NamespaceSimulation{Namespace: ns.Name}
+		qdef, err := r.findQuotaDefinition(ns, func(qd *quotav1alpha1.QuotaDefinition) bool {
+			return qd.GetTarget() == target
+		})
+		if err != nil {
+			return nil, err
+		}
+		if qdef == nil {
+			res = append(res, sim)
+			continue
+		}
+		sim.QuotaDefinition = qdef.Name
+
+		// the API server returns objects sorted by name, so this is what the controller would see
+		nsQis, ok := qisPerNamespace[ns.Name]
+		if !ok {
+			nsQis = &quotav1alpha1.QuotaIncreaseList{}
+		}
+		slices.SortFunc(nsQis.Items, func(a, b quotav1alpha1.QuotaIncrease) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		acceptedQis, rejections := r.applyQuotaIncreaseLimits(ctx, ns, qdef, nsQis)
+		var effects map[string]corev1.ResourceList
+		sim.ResourceQuota, effects = r.computeResourceQuota(ctx, ns, qdef, acceptedQis)
+		if qdef.LimitRangeTemplate != nil {
+			sim.LimitRange = r.computeLimitRange(ns, qdef, sim.ResourceQuota)
+		}
+		for _, qi := range nsQis.Items {
+			qiSim := QuotaIncreaseSimulation{
+				Name:     qi.Name,
+				Effect:   effects[qi.Name],
+				Rejected: rejections[qi.Name],
+			}
+			action, value := quotaIncreaseOutcome(qdef, ns, qi.Name, effects, rejections)
+			switch action {
+			case quotaIncreaseActionAnnotate:
+				qiSim.EffectAnnotation = value
+				qiSim.EffectDetails = quotaIncreaseEffect(qdef, ns, &qi, effects, rejections)
+			case quotaIncreaseActionDelete:
+				qiSim.Deleted = true
+			case quotaIncreaseActionNone:
+				qiSim.EffectAnnotation = qi.Annotations[quotav1alpha1.EffectAnnotation]
+			}
+			sim.QuotaIncreases = append(sim.QuotaIncreases, qiSim)
+		}
+		res = append(res, sim)
+	}
+	slices.SortFunc(res, func(a, b NamespaceSimulation) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package quota_test
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotainstall "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+// loadSimulationInput loads the config, namespaces and QuotaIncreases from the given testdata directory.
+// The operating mode of all quota definitions is set to the given one.
+func loadSimulationInput(mode quotav1alpha1.QuotaIncreaseOperatingMode, testDataPathSegments ...string) (*quotav1alpha1.QuotaServiceConfig, []*corev1.Namespace, []*quotav1alpha1.QuotaIncrease) {
+	scheme := quotainstall.InstallOperatorAPIsOnboarding(quotainstall.InstallOperatorAPIsPlatform(runtime.NewScheme()))
+	var cfg *quotav1alpha1.QuotaServiceConfig
+	namespaces := []*corev1.Namespace{}
+	qis := []*quotav1alpha1.QuotaIncrease{}
+	for _, dir := range []string{"platform", "onboarding"} {
+		objs, err := testutils.LoadObjects(filepath.Join(append(testDataPathSegments, dir)...), scheme)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		for _, obj := range objs {
+			// LoadObjects returns unstructured objects, convert them into their typed counterparts
+			u, ok := obj.(*unstructured.Unstructured)
+			ExpectWithOffset(1, ok).To(BeTrue())
+			typed, err := scheme.New(u.GroupVersionKind())
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed)).To(Succeed())
+			switch typed := typed.(type) {
+			case *quotav1alpha1.QuotaServiceConfig:
+				cfg = typed
+			case *corev1.Namespace:
+				namespaces = append(namespaces, typed)
+			case *quotav1alpha1.QuotaIncrease:
+				qis = append(qis, typed)
+			}
+		}
+	}
+	ExpectWithOffset(1, cfg).ToNot(BeNil())
+	for i := range cfg.Spec.Quotas {
+		cfg.Spec.Quotas[i].Mode = mode
+	}
+	return cfg, namespaces, qis
+}
+
+var _ = Describe("Simulation", func() {
+
+	It("should compute the same result as the controller", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-05")
+
+		sims, err := quotacontroller.Simulate(logging.NewContextWithDiscard(context.Background()), cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, namespaces, qis)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sims).To(HaveLen(1))
+		sim := sims[0]
+		Expect(sim.Namespace).To(Equal("ns-normal"))
+		Expect(sim.QuotaDefinition).To(Equal("limited"))
+		Expect(sim.ResourceQuota.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), matchNumericQuantity(12)))
+		Expect(sim.LimitRange).To(BeNil())
+		Expect(sim.QuotaIncreases).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Name":             Equal("qi-normal-alpha"),
+				"EffectAnnotation": Equal("count/secrets: 5"),
+				"Rejected":         BeEmpty(),
+				"Deleted":          BeFalse(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":             Equal("qi-normal-beta"),
+				"EffectAnnotation": Equal("count/secrets: 4"),
+				"Rejected":         BeEmpty(),
+				"Deleted":          BeFalse(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":             Equal("qi-normal-delta"),
+				"EffectAnnotation": HavePrefix(quotav1alpha1.RejectedQuotaIncreaseEffectPrefix + " maximum number"),
+				"Rejected":         Not(BeEmpty()),
+				"Deleted":          BeFalse(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":             Equal("qi-normal-gamma"),
+				"EffectAnnotation": HavePrefix(quotav1alpha1.RejectedQuotaIncreaseEffectPrefix + " maximum increase"),
+				"Rejected":         Not(BeEmpty()),
+				"Deleted":          BeFalse(),
+			}),
+		))
+	})
+
+	It("should compute LimitRanges and only consider quota definitions with the given target", func() {
+		cfg, namespaces, qis := loadSimulationInput(quotav1alpha1.CUMULATIVE, "testdata", "test-04")
+
+		sims, err := quotacontroller.Simulate(logging.NewContextWithDiscard(context.Background()), cfg, providerName, quotav1alpha1.TARGET_ONBOARDING, namespaces, qis)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sims).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Namespace":       Equal("ns-normal"),
+				"QuotaDefinition": Equal("all"),
+				"LimitRange":      Not(BeNil()),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Namespace":       Equal("ns-scaled"),
+				"QuotaDefinition": Equal("scaled"),
+				"LimitRange":      Not(BeNil()),
+			}),
+		))
+
+		sims, err = quotacontroller.Simulate(logging.NewContextWithDiscard(context.Background()), cfg, providerName, quotav1alpha1.TARGET_MCP, namespaces, qis)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sims).To(HaveEach(MatchFields(IgnoreExtras, Fields{
+			"QuotaDefinition": BeEmpty(),
+			"ResourceQuota":   BeNil(),
+		})))
+	})
+
+})
@@ -0,0 +1,280 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+)
+
+const (
+	StagedRolloutControllerName = "quota-staged-rollout"
+
+	// minRolloutRequeueInterval is the minimum time between two batches,
+	// which gives the quota controller the chance to reconcile the updated namespaces before their usage is evaluated.
+	minRolloutRequeueInterval = time.Second
+)
+
+// NewStagedRolloutController creates a new StagedRolloutController for the configs of the given QuotaController.
+func NewStagedRolloutController(qc *QuotaController) *StagedRolloutController {
+	return &StagedRolloutController{
+		Quota: qc,
+		Now:   time.Now,
+	}
+}
+
+// StagedRolloutController rolls out new generations of the QuotaServiceConfig which have a rollout policy to the onboarding namespaces, batch by batch.
+// A namespace is updated by setting the config generation label on it, which makes the QuotaController use the new generation for it.
+// Only namespaces whose quota definition differs between the stable and the new generation are part of the rollout.
+// The progress is reported in the status of the QuotaServiceConfig.
+type StagedRolloutController struct {
+	Quota *QuotaController
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Reconcile advances the staged rollout which is currently in progress, if any.
+// It halts the rollout if too many updated namespaces are below usage, completes it if all affected namespaces have been updated,
+// and otherwise updates the next batch of namespaces once the pause since the last batch has passed.
+func (r *StagedRolloutController) Reconcile(ctx context.Context, req reconcile.Request) (_ reconcile.Result, err error) {
+	defer func() { r.Quota.ErrorRate.Record(err) }()
+	log := logging.FromContextOrPanic(ctx).WithName(StagedRolloutControllerName)
+	ctx = logging.NewContext(ctx, log)
+	log.Debug("Reconcile triggered")
+
+	stable, target := r.Quota.Configs.Rollout()
+	if stable == nil {
+		log.Debug("No staged rollout in progress")
+		return ctrl.Result{}, nil
+	}
+	log = log.WithValues("generation", target.Generation, "stableGeneration", stable.Generation)
+	ctx = logging.NewContext(ctx, log)
+
+	cfg := &quotav1alpha1.QuotaServiceConfig{}
+	if err := r.Quota.PlatformCluster.Client().Get(ctx, types.NamespacedName{Name: target.Name}, cfg); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the config watcher keeps the active config, but there is no status to report the progress in
+			log.Debug("QuotaServiceConfig not found, pausing rollout")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to fetch QuotaServiceConfig '%s': %w", target.Name, err)
+	}
+	if cfg.UID != target.UID || cfg.Generation != target.Generation {
+		// the config watcher triggers a new reconcile once it has processed the new generation
+		log.Debug("QuotaServiceConfig has changed, waiting for the config watcher")
+		return ctrl.Result{}, nil
+	}
+	rs := cfg.Status.Rollout
+	if rs == nil || rs.Generation != target.Generation {
+		rs = newRolloutStatus(stable, target)
+	} else {
+		rs = rs.DeepCopy()
+	}
+	switch rs.Phase {
+	case quotav1alpha1.ROLLOUT_HALTED:
+		log.Debug("Rollout is halted")
+		return ctrl.Result{}, nil
+	case quotav1alpha1.ROLLOUT_COMPLETED:
+		r.Quota.Configs.CompleteRollout(target.Generation)
+		return ctrl.Result{}, nil
+	}
+
+	selectors := newSelectorCache(stable, target)
+	updated, pending, err := r.affectedNamespaces(ctx, selectors, stable, target)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	belowUsage, err := r.countBelowUsage(ctx, selectors, target, updated)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	rs.UpdatedNamespaces = int32(len(updated))
+	rs.TotalNamespaces = int32(len(updated) + len(pending))
+	rs.BelowUsageNamespaces = int32(belowUsage)
+
+	policy := target.Spec.Rollout
+	now := r.now()
+	res := ctrl.Result{}
+	var remainingPause time.Duration
+	if rs.LastBatchTime != nil {
+		remainingPause = rs.LastBatchTime.Add(policy.GetPause()).Sub(now)
+	}
+	switch {
+	case policy.MaxBelowUsage > 0 && rs.BelowUsageNamespaces >= policy.MaxBelowUsage:
+		rs.Phase = quotav1alpha1.ROLLOUT_HALTED
+		rs.Message = fmt.Sprintf("Rollout halted, %d updated namespaces are below usage (maximum: %d). Namespaces which have not been updated yet keep using generation %d until a new generation is created.", belowUsage, policy.MaxBelowUsage, stable.Generation)
+		log.Info("Halting staged rollout, too many namespaces are below usage", "belowUsageNamespaces", belowUsage, "maxBelowUsage", policy.MaxBelowUsage)
+	case remainingPause > 0:
+		log.Debug("Waiting for pause between batches", "remaining", remainingPause)
+		res.RequeueAfter = remainingPause
+	case len(pending) == 0:
+		rs.Phase = quotav1alpha1.ROLLOUT_COMPLETED
+		rs.Message = fmt.Sprintf("All %d affected namespaces use generation %d.", rs.TotalNamespaces, target.Generation)
+		r.Quota.Configs.CompleteRollout(target.Generation)
+		log.Info("Completed staged rollout", "updatedNamespaces", len(updated))
+	default:
+		batch := nextRolloutBatch(pending, policy, int(rs.TotalNamespaces))
+		if err := r.updateNamespaces(ctx, batch, target.Generation); err != nil {
+			return ctrl.Result{}, err
+		}
+		rs.UpdatedNamespaces += int32(len(batch))
+		rs.LastBatchTime = &metav1.Time{Time: now}
+		rs.Message = fmt.Sprintf("Updated %d of %d affected namespaces to generation %d.", rs.UpdatedNamespaces, rs.TotalNamespaces, target.Generation)
+		log.Info("Updated batch of namespaces", "batchSize", len(batch), "updatedNamespaces", rs.UpdatedNamespaces, "totalNamespaces", rs.TotalNamespaces)
+		res.RequeueAfter = max(policy.GetPause(), minRolloutRequeueInterval)
+	}
+
+	if err := r.updateStatus(ctx, cfg, rs); err != nil {
+		return ctrl.Result{}, err
+	}
+	return res, nil
+}
+
+// affectedNamespaces returns the onboarding namespaces whose quota definition differs between the stable and the target config,
+// split into the ones which have already been updated to the target generation and the pending ones.
+// Both lists are sorted by name.
+func (r *StagedRolloutController) affectedNamespaces(ctx context.Context, selectors *selectorCache, stable, target *quotav1alpha1.QuotaServiceConfig) (updated, pending []corev1.Namespace, err error) {
+	nsList := &corev1.NamespaceList{}
+	if err := r.Quota.reader(r.Quota.OnboardingCluster).List(ctx, nsList); err != nil {
+		return nil, nil, fmt.Errorf("error listing namespaces: %w", err)
+	}
+	generation := strconv.FormatInt(target.Generation, 10)
+	for _, ns := range nsList.Items {
+		if !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if managedBy, ok := ns.Labels[quotav1alpha1.ManagedByLabel]; ok && managedBy != r.Quota.ProviderName {
+			continue
+		}
+		if !quotaDefinitionChanged(selectors, stable, target, &ns, onboardingTarget) {
+			continue
+		}
+		if ns.Labels[quotav1alpha1.ConfigGenerationLabel] == generation {
+			updated = append(updated, ns)
+		} else {
+			pending = append(pending, ns)
+		}
+	}
+	byName := func(a, b corev1.Namespace) int { return strings.Compare(a.Name, b.Name) }
+	slices.SortFunc(updated, byName)
+	slices.SortFunc(pending, byName)
+	return updated, pending, nil
+}
+
+// countBelowUsage returns the number of the given namespaces whose ResourceQuota for the target config has a usage which exceeds the hard limit for at least one resource.
+func (r *StagedRolloutController) countBelowUsage(ctx context.Context, selectors *selectorCache, target *quotav1alpha1.QuotaServiceConfig, namespaces []corev1.Namespace) (int, error) {
+	count := 0
+	for _, ns := range namespaces {
+		qdef, err := matchQuotaDefinition(selectors, target.Spec.Quotas, &ns, onboardingTarget)
+		if err != nil {
+			return 0, err
+		}
+		if qdef == nil {
+			continue
+		}
+		rq := &corev1.ResourceQuota{}
+		if err := r.Quota.OnboardingCluster.Client().Get(ctx, types.NamespacedName{Name: qdef.Name, Namespace: ns.Name}, rq); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return 0, fmt.Errorf("error fetching ResourceQuota '%s/%s': %w", ns.Name, qdef.Name, err)
+		}
+		if usageExceedsQuota(rq) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// usageExceedsQuota returns true if the used amount of any resource exceeds the hard limit of the ResourceQuota.
+func usageExceedsQuota(rq *corev1.ResourceQuota) bool {
+	for res, hard := range rq.Spec.Hard {
+		if used, ok := rq.Status.Used[res]; ok && used.Cmp(hard) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nextRolloutBatch returns the namespaces which are updated next.
+// Pending canary namespaces form a batch of their own, all other namespaces are updated in batches of the configured size.
+func nextRolloutBatch(pending []corev1.Namespace, policy *quotav1alpha1.RolloutPolicy, total int) []corev1.Namespace {
+	if policy.Canary != nil {
+		// the selector has been validated together with the config
+		sel, err := metav1.LabelSelectorAsSelector(policy.Canary)
+		if err == nil {
+			canaries := []corev1.Namespace{}
+			for _, ns := range pending {
+				if sel.Matches(labels.Set(ns.Labels)) {
+					canaries = append(canaries, ns)
+				}
+			}
+			if len(canaries) > 0 {
+				return canaries
+			}
+		}
+	}
+	return pending[:min(policy.GetBatchSize(total), len(pending))]
+}
+
+// updateNamespaces sets the config generation label on the given namespaces.
+// This triggers a reconciliation of the namespaces, which then use the given generation.
+func (r *StagedRolloutController) updateNamespaces(ctx context.Context, namespaces []corev1.Namespace, generation int64) error {
+	for _, ns := range namespaces {
+		old := ns.DeepCopy()
+		metav1.SetMetaDataLabel(&ns.ObjectMeta, quotav1alpha1.ConfigGenerationLabel, strconv.FormatInt(generation, 10))
+		if err := r.Quota.OnboardingCluster.Client().Patch(ctx, &ns, client.MergeFrom(old)); err != nil {
+			return fmt.Errorf("error updating config generation label on namespace '%s': %w", ns.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateStatus writes the given rollout status into the status of the config, if it changed.
+func (r *StagedRolloutController) updateStatus(ctx context.Context, cfg *quotav1alpha1.QuotaServiceConfig, rs *quotav1alpha1.RolloutStatus) error {
+	if equality.Semantic.DeepEqual(cfg.Status.Rollout, rs) {
+		return nil
+	}
+	old := cfg.DeepCopy()
+	cfg.Status.Rollout = rs
+	if err := r.Quota.PlatformCluster.Client().Status().Patch(ctx, cfg, client.MergeFrom(old)); err != nil {
+		return fmt.Errorf("error updating rollout status of QuotaServiceConfig '%s': %w", cfg.Name, err)
+	}
+	return nil
+}
+
+func (r *StagedRolloutController) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// It is triggered whenever a new config becomes active and requeues itself while a rollout is in progress.
+func (r *StagedRolloutController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(StagedRolloutControllerName).
+		WatchesRawSource(source.Channel(r.Quota.Configs.Subscribe(), handler.TypedEnqueueRequestsFromMapFunc(func(_ context.Context, cfg *quotav1alpha1.QuotaServiceConfig) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: cfg.Name}}}
+		}))).
+		Complete(r)
+}
@@ -0,0 +1,241 @@
+package quota_test
+
+import (
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	testutils "github.com/openmcp-project/controller-utils/pkg/testing"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	quotacontroller "github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const rolloutRec = "rollout"
+
+var _ = Describe("Staged Rollout", func() {
+
+	var env *testutils.ComplexEnvironment
+	var qc *quotacontroller.QuotaController
+	var now time.Time
+	var stableGeneration int64
+
+	namespaces := []string{"ns-normal", "ns-project", "ns-workspace"}
+	pause := 10 * time.Minute
+
+	getConfig := func() *quotav1alpha1.QuotaServiceConfig {
+		cfg := &quotav1alpha1.QuotaServiceConfig{}
+		cfg.SetName(providerName)
+		ExpectWithOffset(1, env.Client(platformCluster).Get(env.Ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+		return cfg
+	}
+
+	// hardLimits reconciles the namespace and returns the hard limits of its generated ResourceQuota.
+	hardLimits := func(nsName, qdefName string) corev1.ResourceList {
+		ns := &corev1.Namespace{}
+		ns.SetName(nsName)
+		env.ShouldReconcile(rec, testutils.RequestFromObject(ns))
+		rq := &corev1.ResourceQuota{}
+		rq.SetName(qdefName)
+		rq.SetNamespace(nsName)
+		ExpectWithOffset(1, env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+		return rq.Spec.Hard
+	}
+
+	// updatedNamespaces returns the names of all namespaces which have been updated to the given generation.
+	updatedNamespaces := func(generation int64) []string {
+		nsList := &corev1.NamespaceList{}
+		ExpectWithOffset(1, env.Client(onboardingCluster).List(env.Ctx, nsList, client.MatchingLabels{quotav1alpha1.ConfigGenerationLabel: strconv.FormatInt(generation, 10)})).To(Succeed())
+		names := []string{}
+		for _, ns := range nsList.Items {
+			names = append(names, ns.Name)
+		}
+		return names
+	}
+
+	reconcileRollout := func() {
+		env.ShouldReconcile(rolloutRec, testutils.RequestFromObject(getConfig()))
+	}
+
+	// lowerBaseQuotas lowers the base quotas of all used quota definitions from 3 to 2 and sets the given rollout policy.
+	lowerBaseQuotas := func(policy *quotav1alpha1.RolloutPolicy) {
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Rollout = policy
+			cfg.Spec.GetQuotaDefinitionForName("project").ResourceQuotaTemplate.Spec.Hard["count/secrets"] = resource.MustParse("2")
+			cfg.Spec.GetQuotaDefinitionForName("workspace").ResourceQuotaTemplate.Spec.Hard["count/configmaps"] = resource.MustParse("2")
+			cfg.Spec.GetQuotaDefinitionForName("all").ResourceQuotaTemplate.Spec.Hard["count/serviceaccounts"] = resource.MustParse("2")
+		})
+	}
+
+	BeforeEach(func() {
+		env = defaultTestSetup(quotav1alpha1.CUMULATIVE, false, "testdata", "test-01")
+		qc = env.Reconciler(rec).(*quotacontroller.QuotaController)
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		src := quotacontroller.NewStagedRolloutController(qc)
+		src.Now = func() time.Time { return now }
+		env.Reconcilers[rolloutRec] = src
+		stableGeneration = getConfig().Generation
+
+		for _, ns := range namespaces {
+			n := &corev1.Namespace{}
+			n.SetName(ns)
+			env.ShouldReconcile(rec, testutils.RequestFromObject(n))
+		}
+	})
+
+	It("should roll out a new generation batch by batch", func() {
+		batchSize := intstr.FromInt32(1)
+		lowerBaseQuotas(&quotav1alpha1.RolloutPolicy{
+			BatchSize: &batchSize,
+			Pause:     &metav1.Duration{Duration: pause},
+		})
+		generation := getConfig().Generation
+
+		// the status is initialized by the config watcher and no namespace has been updated yet
+		rs := getConfig().Status.Rollout
+		Expect(rs).ToNot(BeNil())
+		Expect(rs.Phase).To(Equal(quotav1alpha1.ROLLOUT_PROGRESSING))
+		Expect(rs.Generation).To(Equal(generation))
+		Expect(rs.StableGeneration).To(Equal(stableGeneration))
+		Expect(hardLimits("ns-normal", "all")).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+
+		// first batch
+		res := env.ShouldReconcile(rolloutRec, testutils.RequestFromObject(getConfig()))
+		Expect(res.RequeueAfter).To(Equal(pause))
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-normal"))
+		Expect(hardLimits("ns-normal", "all")).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("2")))
+		// the QuotaIncreases in the namespace add 50 secrets to the base quota
+		Expect(hardLimits("ns-project", "project")).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), resource.MustParse("53")))
+		rs = getConfig().Status.Rollout
+		Expect(rs.UpdatedNamespaces).To(BeEquivalentTo(1))
+		Expect(rs.TotalNamespaces).To(BeEquivalentTo(3))
+		Expect(rs.LastBatchTime.Time).To(BeTemporally("==", now))
+
+		// no new batch before the pause has passed
+		now = now.Add(pause / 2)
+		res = env.ShouldReconcile(rolloutRec, testutils.RequestFromObject(getConfig()))
+		Expect(res.RequeueAfter).To(Equal(pause / 2))
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-normal"))
+
+		// remaining batches
+		now = now.Add(pause / 2)
+		reconcileRollout()
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-normal", "ns-project"))
+		Expect(hardLimits("ns-project", "project")).To(HaveKeyWithValue(corev1.ResourceName("count/secrets"), resource.MustParse("52")))
+		now = now.Add(pause)
+		reconcileRollout()
+		Expect(updatedNamespaces(generation)).To(ConsistOf(namespaces))
+
+		// completion after the pause of the last batch
+		now = now.Add(pause)
+		reconcileRollout()
+		rs = getConfig().Status.Rollout
+		Expect(rs.Phase).To(Equal(quotav1alpha1.ROLLOUT_COMPLETED))
+		Expect(rs.UpdatedNamespaces).To(BeEquivalentTo(3))
+		stable, _ := qc.Configs.Rollout()
+		Expect(stable).To(BeNil())
+	})
+
+	It("should update canary namespaces first", func() {
+		lowerBaseQuotas(&quotav1alpha1.RolloutPolicy{
+			Canary: &metav1.LabelSelector{MatchLabels: map[string]string{"openmcp.cloud/workspace": "my-workspace"}},
+			Pause:  &metav1.Duration{Duration: pause},
+		})
+		generation := getConfig().Generation
+
+		reconcileRollout()
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-workspace"))
+		// the QuotaIncrease in the namespace adds one configmap to the base quota
+		Expect(hardLimits("ns-workspace", "workspace")).To(HaveKeyWithValue(corev1.ResourceName("count/configmaps"), resource.MustParse("3")))
+		Expect(hardLimits("ns-normal", "all")).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+
+		// the default batch size covers all remaining namespaces
+		now = now.Add(pause)
+		reconcileRollout()
+		Expect(updatedNamespaces(generation)).To(ConsistOf(namespaces))
+	})
+
+	It("should halt the rollout if too many namespaces are below usage and complete a revert immediately", func() {
+		batchSize := intstr.FromString("34%")
+		lowerBaseQuotas(&quotav1alpha1.RolloutPolicy{
+			BatchSize:     &batchSize,
+			Pause:         &metav1.Duration{Duration: pause},
+			MaxBelowUsage: 1,
+		})
+		generation := getConfig().Generation
+
+		// 34% of 3 namespaces is rounded up to 2
+		reconcileRollout()
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-normal", "ns-project"))
+		hardLimits("ns-normal", "all")
+
+		// usage exceeds the lowered quota, the status is written directly since the fake client has no status subresource for ResourceQuotas
+		rq := &corev1.ResourceQuota{}
+		rq.SetName("all")
+		rq.SetNamespace("ns-normal")
+		Expect(env.Client(onboardingCluster).Get(env.Ctx, client.ObjectKeyFromObject(rq), rq)).To(Succeed())
+		rq.Status.Used = corev1.ResourceList{"count/serviceaccounts": resource.MustParse("3")}
+		Expect(env.Client(onboardingCluster).Update(env.Ctx, rq)).To(Succeed())
+
+		now = now.Add(pause)
+		res := env.ShouldReconcile(rolloutRec, testutils.RequestFromObject(getConfig()))
+		Expect(res.RequeueAfter).To(BeZero())
+		rs := getConfig().Status.Rollout
+		Expect(rs.Phase).To(Equal(quotav1alpha1.ROLLOUT_HALTED))
+		Expect(rs.BelowUsageNamespaces).To(BeEquivalentTo(1))
+		Expect(updatedNamespaces(generation)).To(ConsistOf("ns-normal", "ns-project"))
+		Expect(hardLimits("ns-workspace", "workspace")).To(HaveKeyWithValue(corev1.ResourceName("count/configmaps"), resource.MustParse("4")))
+
+		// a halted rollout is not resumed
+		now = now.Add(pause)
+		reconcileRollout()
+		Expect(getConfig().Status.Rollout.Phase).To(Equal(quotav1alpha1.ROLLOUT_HALTED))
+
+		// reverting the change does not affect any namespace compared to the stable generation
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.GetQuotaDefinitionForName("project").ResourceQuotaTemplate.Spec.Hard["count/secrets"] = resource.MustParse("3")
+			cfg.Spec.GetQuotaDefinitionForName("workspace").ResourceQuotaTemplate.Spec.Hard["count/configmaps"] = resource.MustParse("3")
+			cfg.Spec.GetQuotaDefinitionForName("all").ResourceQuotaTemplate.Spec.Hard["count/serviceaccounts"] = resource.MustParse("3")
+		})
+		rs = getConfig().Status.Rollout
+		Expect(rs.StableGeneration).To(Equal(stableGeneration))
+		Expect(hardLimits("ns-normal", "all")).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+		reconcileRollout()
+		rs = getConfig().Status.Rollout
+		Expect(rs.Phase).To(Equal(quotav1alpha1.ROLLOUT_COMPLETED))
+		Expect(rs.TotalNamespaces).To(BeZero())
+	})
+
+	It("should continue a rollout after a restart", func() {
+		lowerBaseQuotas(&quotav1alpha1.RolloutPolicy{})
+		generation := getConfig().Generation
+
+		watcher := quotacontroller.NewConfigWatcher(qc.PlatformCluster, providerName)
+		_, err := watcher.Reconcile(env.Ctx, testutils.RequestFromObject(getConfig()))
+		Expect(err).ToNot(HaveOccurred())
+		stable, active := watcher.Rollout()
+		Expect(active.Generation).To(Equal(generation))
+		Expect(stable).ToNot(BeNil())
+		Expect(stable.Generation).To(Equal(stableGeneration))
+		Expect(stable.Spec.GetQuotaDefinitionForName("all").ResourceQuotaTemplate.Spec.Hard).To(HaveKeyWithValue(corev1.ResourceName("count/serviceaccounts"), resource.MustParse("3")))
+	})
+
+	It("should reject invalid rollout policies", func() {
+		batchSize := intstr.FromString("0%")
+		updateConfig(env, func(cfg *quotav1alpha1.QuotaServiceConfig) {
+			cfg.Spec.Rollout = &quotav1alpha1.RolloutPolicy{
+				BatchSize: &batchSize,
+				Pause:     &metav1.Duration{Duration: -time.Minute},
+			}
+		})
+		Expect(qc.Configs.Check(nil)).To(MatchError(And(ContainSubstring("spec.rollout.batchSize"), ContainSubstring("spec.rollout.pause"))))
+	})
+
+})
@@ -0,0 +1,384 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/openmcp-project/controller-utils/pkg/logging"
+
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+)
+
+const (
+	// PathPrefix is the common prefix of all API endpoints.
+	PathPrefix = "/api/v1"
+)
+
+// ConfigProvider returns the QuotaServiceConfig which is currently used by the controller, or nil if none has been loaded yet.
+type ConfigProvider interface {
+	ActiveConfig() *quotav1alpha1.QuotaServiceConfig
+}
+
+// NamespaceState is the quota state of a single namespace, as returned by the API.
+type NamespaceState struct {
+	// Namespace is the name of the namespace.
+	Namespace string `json:"namespace"`
+	// QuotaDefinition is the name of the quota definition which is applied to the namespace.
+	QuotaDefinition string `json:"quotaDefinition"`
+	// Mode is the operating mode of the quota definition.
+	Mode string `json:"mode,omitempty"`
+	// UsedQuotaIncrease is the QuotaIncrease selected via label in operating mode 'singular'.
+	UsedQuotaIncrease string `json:"usedQuotaIncrease,omitempty"`
+	// Hard contains the hard limits of the generated ResourceQuota.
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+	// Used contains the usage, as reported in the status of the generated ResourceQuota.
+	Used corev1.ResourceList `json:"used,omitempty"`
+	// QuotaIncreases contains the QuotaIncreases in the namespace, sorted by name.
+	// Only filled for requests for a single namespace.
+	QuotaIncreases []QuotaIncreaseState `json:"quotaIncreases,omitempty"`
+}
+
+// QuotaIncreaseState is the state of a single QuotaIncrease, as returned by the API.
+type QuotaIncreaseState struct {
+	// Name is the name of the QuotaIncrease.
+	Name string `json:"name"`
+	// CreationTimestamp is the creation timestamp of the QuotaIncrease.
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	// Requested contains the amounts requested by the QuotaIncrease.
+	Requested corev1.ResourceList `json:"requested"`
+	// Evaluated is false if the controller has not evaluated the QuotaIncrease yet.
+	Evaluated bool `json:"evaluated"`
+	// Effect is the value of the effect annotation of the QuotaIncrease.
+	Effect string `json:"effect,omitempty"`
+	// Rejected is true if the QuotaIncrease has been rejected due to the limits of the quota definition.
+	Rejected bool `json:"rejected,omitempty"`
+	// EffectDetails is the parsed value of the effect details annotation of the QuotaIncrease. Nil if the annotation is missing or invalid.
+	EffectDetails *quota.QuotaIncreaseEffect `json:"effectDetails,omitempty"`
+}
+
+// ConfigState is the active QuotaServiceConfig, as returned by the API.
+type ConfigState struct {
+	// Name is the name of the QuotaServiceConfig.
+	Name string `json:"name"`
+	// Generation is the generation of the QuotaServiceConfig which is currently used.
+	Generation int64 `json:"generation"`
+	// Spec is the spec of the QuotaServiceConfig.
+	Spec quotav1alpha1.QuotaServiceConfigSpec `json:"spec"`
+}
+
+// Handler serves the read-only quota API.
+// All data is read via the given client, which is expected to be backed by the manager's cache.
+type Handler struct {
+	Client         client.Client
+	ProviderName   string
+	ConfigProvider ConfigProvider
+	// AuditLog contains the changes which have been skipped in audit mode. Nil if the controller does not run in audit mode.
+	AuditLog *quota.AuditLog
+	mux      *http.ServeMux
+}
+
+// NewHandler creates a new Handler for the API.
+// The endpoints are:
+//   - GET /api/v1/config: the active QuotaServiceConfig
+//   - GET /api/v1/namespaces: the quota state of all namespaces managed by the controller with the given provider name
+//   - GET /api/v1/namespaces/{namespace}: the quota state of a single namespace, including its QuotaIncreases
+//   - GET /api/v1/namespaces/{namespace}/quotaincreases: the QuotaIncreases of a single namespace and their effects
+//   - GET /api/v1/audit: the changes which are pending because they have been skipped in audit mode, see WithAuditLog
+//
+// The client is expected to be backed by a cache with the field indexes from quota.SetupFieldIndexes.
+func NewHandler(cli client.Client, providerName string, cfgProvider ConfigProvider) *Handler {
+	h := &Handler{
+		Client:         cli,
+		ProviderName:   providerName,
+		ConfigProvider: cfgProvider,
+		mux:            http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET "+PathPrefix+"/config", h.getConfig)
+	h.mux.HandleFunc("GET "+PathPrefix+"/namespaces", h.listNamespaces)
+	h.mux.HandleFunc("GET "+PathPrefix+"/namespaces/{namespace}", h.getNamespace)
+	h.mux.HandleFunc("GET "+PathPrefix+"/namespaces/{namespace}/quotaincreases", h.listQuotaIncreases)
+	h.mux.HandleFunc("GET "+PathPrefix+"/audit", h.listAuditChanges)
+	return h
+}
+
+// WithAuditLog sets the audit log whose pending changes are served. A nil audit log disables the audit endpoint.
+func (h *Handler) WithAuditLog(auditLog *quota.AuditLog) *Handler {
+	h.AuditLog = auditLog
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mux.ServeHTTP(w, req)
+}
+
+func (h *Handler) getConfig(w http.ResponseWriter, req *http.Request) {
+	cfg := h.ConfigProvider.ActiveConfig()
+	if cfg == nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("no QuotaServiceConfig has been loaded yet"))
+		return
+	}
+	writeJSON(w, req, http.StatusOK, &ConfigState{
+		Name:       cfg.Name,
+		Generation: cfg.Generation,
+		Spec:       cfg.Spec,
+	})
+}
+
+func (h *Handler) listAuditChanges(w http.ResponseWriter, req *http.Request) {
+	if h.AuditLog == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("the controller does not run in audit mode"))
+		return
+	}
+	writeJSON(w, req, http.StatusOK, h.AuditLog.Changes())
+}
+
+func (h *Handler) listNamespaces(w http.ResponseWriter, req *http.Request) {
+	nsList := &corev1.NamespaceList{}
+	if err := h.Client.List(req.Context(), nsList, client.MatchingFields{quota.ManagedByIndex: h.ProviderName}); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error listing namespaces: %w", err))
+		return
+	}
+	rqList := &corev1.ResourceQuotaList{}
+	if err := h.Client.List(req.Context(), rqList, client.MatchingFields{quota.ManagedByIndex: h.ProviderName}); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error listing ResourceQuotas: %w", err))
+		return
+	}
+	res := make([]NamespaceState, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		state := namespaceState(&ns)
+		for _, rq := range rqList.Items {
+			if rq.Namespace == ns.Name && rq.Labels[quotav1alpha1.QuotaDefinitionLabel] == state.QuotaDefinition {
+				state.Hard = rq.Spec.Hard
+				state.Used = rq.Status.Used
+				break
+			}
+		}
+		res = append(res, *state)
+	}
+	slices.SortFunc(res, func(a, b NamespaceState) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	writeJSON(w, req, http.StatusOK, res)
+}
+
+func (h *Handler) getNamespace(w http.ResponseWriter, req *http.Request) {
+	ns, ok := h.managedNamespace(w, req)
+	if !ok {
+		return
+	}
+	state := namespaceState(ns)
+	rqList := &corev1.ResourceQuotaList{}
+	if err := h.Client.List(req.Context(), rqList, client.InNamespace(ns.Name), client.MatchingLabels{
+		quotav1alpha1.ManagedByLabel:       h.ProviderName,
+		quotav1alpha1.QuotaDefinitionLabel: state.QuotaDefinition,
+	}); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error listing ResourceQuotas: %w", err))
+		return
+	}
+	if len(rqList.Items) > 0 {
+		state.Hard = rqList.Items[0].Spec.Hard
+		state.Used = rqList.Items[0].Status.Used
+	}
+	qis, err := h.quotaIncreaseStates(req.Context(), ns.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	state.QuotaIncreases = qis
+	writeJSON(w, req, http.StatusOK, state)
+}
+
+func (h *Handler) listQuotaIncreases(w http.ResponseWriter, req *http.Request) {
+	ns, ok := h.managedNamespace(w, req)
+	if !ok {
+		return
+	}
+	qis, err := h.quotaIncreaseStates(req.Context(), ns.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, req, http.StatusOK, qis)
+}
+
+// managedNamespace fetches the namespace from the request path.
+// If it does not exist or is not managed by the controller, an error response is written and false is returned.
+func (h *Handler) managedNamespace(w http.ResponseWriter, req *http.Request) (*corev1.Namespace, bool) {
+	name := req.PathValue("namespace")
+	ns := &corev1.Namespace{}
+	if err := h.Client.Get(req.Context(), client.ObjectKey{Name: name}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("namespace '%s' not found", name))
+			return nil, false
+		}
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error getting namespace '%s': %w", name, err))
+		return nil, false
+	}
+	if ns.Labels[quotav1alpha1.ManagedByLabel] != h.ProviderName {
+		// don't reveal the existence of namespaces which are not managed by this controller
+		writeError(w, http.StatusNotFound, fmt.Errorf("namespace '%s' not found", name))
+		return nil, false
+	}
+	return ns, true
+}
+
+// quotaIncreaseStates returns the state of all QuotaIncreases in the given namespace, sorted by name.
+func (h *Handler) quotaIncreaseStates(ctx context.Context, namespace string) ([]QuotaIncreaseState, error) {
+	qiList := &quotav1alpha1.QuotaIncreaseList{}
+	if err := h.Client.List(ctx, qiList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("error listing QuotaIncreases: %w", err)
+	}
+	res := make([]QuotaIncreaseState, 0, len(qiList.Items))
+	for _, qi := range qiList.Items {
+		effect, evaluated := qi.Annotations[quotav1alpha1.EffectAnnotation]
+		state := QuotaIncreaseState{
+			Name:              qi.Name,
+			CreationTimestamp: qi.CreationTimestamp,
+			Requested:         qi.Spec.Hard,
+			Evaluated:         evaluated,
+			Effect:            effect,
+			Rejected:          strings.HasPrefix(effect, quotav1alpha1.RejectedQuotaIncreaseEffectPrefix),
+		}
+		if details, ok := qi.Annotations[quotav1alpha1.EffectDetailsAnnotation]; ok {
+			state.EffectDetails = &quota.QuotaIncreaseEffect{}
+			if err := json.Unmarshal([]byte(details), state.EffectDetails); err != nil {
+				// the annotation is maintained by the controller, an invalid value is not worth failing the request
+				state.EffectDetails = nil
+			}
+		}
+		res = append(res, state)
+	}
+	slices.SortFunc(res, func(a, b QuotaIncreaseState) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return res, nil
+}
+
+// namespaceState returns the state of the namespace, without ResourceQuota and QuotaIncrease information.
+func namespaceState(ns *corev1.Namespace) *NamespaceState {
+	return &NamespaceState{
+		Namespace:         ns.Name,
+		QuotaDefinition:   ns.Labels[quotav1alpha1.BaseQuotaLabel],
+		Mode:              ns.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel],
+		UsedQuotaIncrease: ns.Labels[quotav1alpha1.SingularQuotaIncreaseLabel],
+	}
+}
+
+func writeJSON(w http.ResponseWriter, req *http.Request, status int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error marshalling response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		logging.FromContextOrDiscard(req.Context()).Error(err, "Error writing response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// Server serves the API handler. It implements manager.Runnable.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string
+	// SecureServing enables TLS. If CertWatcher is nil, a self-signed certificate is generated.
+	SecureServing bool
+	// TLSOpts are applied to the TLS config, if SecureServing is true.
+	TLSOpts []func(*tls.Config)
+	// CertWatcher provides the serving certificate. Optional.
+	CertWatcher *certwatcher.CertWatcher
+	// Filter wraps the handler, e.g. for authentication and authorization. Optional.
+	Filter metricsserver.Filter
+	// Handler is the handler to serve.
+	Handler http.Handler
+	// Log is used for logging.
+	Log logging.Logger
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// The API is read-only and served by all replicas.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start starts the server and blocks until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	handler := s.Handler
+	if s.Filter != nil {
+		var err error
+		handler, err = s.Filter(s.Log.Logr(), handler)
+		if err != nil {
+			return fmt.Errorf("error applying filter to API handler: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", s.BindAddress)
+	if err != nil {
+		return fmt.Errorf("error listening on '%s': %w", s.BindAddress, err)
+	}
+	if s.SecureServing {
+		cfg := &tls.Config{
+			NextProtos: []string{"h2"},
+		}
+		for _, opt := range s.TLSOpts {
+			opt(cfg)
+		}
+		if s.CertWatcher != nil {
+			cfg.GetCertificate = s.CertWatcher.GetCertificate
+		} else {
+			cert, key, err := certutil.GenerateSelfSignedCertKey("localhost", []net.IP{{127, 0, 0, 1}}, nil)
+			if err != nil {
+				return fmt.Errorf("error generating self-signed certificate for API server: %w", err)
+			}
+			keyPair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return fmt.Errorf("error creating self-signed key pair for API server: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{keyPair}
+		}
+		listener = tls.NewListener(listener, cfg)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 32 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.Log.Error(err, "Error shutting down API server")
+		}
+	}()
+
+	s.Log.Info("Starting API server", "address", listener.Addr().String(), "secure", s.SecureServing)
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving API: %w", err)
+	}
+	return nil
+}
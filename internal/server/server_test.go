@@ -0,0 +1,189 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotainstall "github.com/openmcp-project/platform-service-quota/api/install"
+	quotav1alpha1 "github.com/openmcp-project/platform-service-quota/api/v1alpha1"
+	"github.com/openmcp-project/platform-service-quota/internal/controller/quota"
+	"github.com/openmcp-project/platform-service-quota/internal/server"
+)
+
+const providerName = "quota"
+
+func TestServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quota API Server Test Suite")
+}
+
+type staticConfigProvider struct {
+	cfg *quotav1alpha1.QuotaServiceConfig
+}
+
+func (p *staticConfigProvider) ActiveConfig() *quotav1alpha1.QuotaServiceConfig {
+	return p.cfg.DeepCopy()
+}
+
+var _ = Describe("API", func() {
+
+	var cfgProvider *staticConfigProvider
+	var handler *server.Handler
+
+	// get performs a GET request against the handler and unmarshals the response body into the given object, if not nil.
+	get := func(path string, into any) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		if into != nil {
+			Expect(json.Unmarshal(rec.Body.Bytes(), into)).To(Succeed())
+		}
+		return rec.Code
+	}
+
+	BeforeEach(func() {
+		newNamespace := func(name, managedBy, qdefName, mode string) *corev1.Namespace {
+			ns := &corev1.Namespace{}
+			ns.SetName(name)
+			ns.SetLabels(map[string]string{})
+			if managedBy != "" {
+				ns.Labels[quotav1alpha1.ManagedByLabel] = managedBy
+				ns.Labels[quotav1alpha1.BaseQuotaLabel] = qdefName
+				ns.Labels[quotav1alpha1.QuotaIncreaseOperationModeLabel] = mode
+			}
+			return ns
+		}
+		newQuotaIncrease := func(name, namespace string, effect *string, hard corev1.ResourceList) *quotav1alpha1.QuotaIncrease {
+			qi := &quotav1alpha1.QuotaIncrease{}
+			qi.SetName(name)
+			qi.SetNamespace(namespace)
+			if effect != nil {
+				qi.SetAnnotations(map[string]string{quotav1alpha1.EffectAnnotation: *effect})
+			}
+			qi.Spec.Hard = hard
+			return qi
+		}
+		applied := "count/secrets: 2"
+		rejected := quotav1alpha1.RejectedQuotaIncreaseEffectPrefix + " exceeds maximum"
+		rq := &corev1.ResourceQuota{}
+		rq.SetName("large")
+		rq.SetNamespace("ns-a")
+		rq.SetLabels(map[string]string{
+			quotav1alpha1.ManagedByLabel:       providerName,
+			quotav1alpha1.QuotaDefinitionLabel: "large",
+		})
+		rq.Spec.Hard = corev1.ResourceList{"count/secrets": resource.MustParse("12")}
+		rq.Status.Used = corev1.ResourceList{"count/secrets": resource.MustParse("4")}
+
+		objs := []client.Object{
+			newNamespace("ns-b", providerName, "small", string(quotav1alpha1.CUMULATIVE)),
+			newNamespace("ns-a", providerName, "large", string(quotav1alpha1.CUMULATIVE)),
+			newNamespace("ns-foreign", "foreign", "large", ""),
+			newNamespace("ns-unmanaged", "", "", ""),
+			newQuotaIncrease("qi-2", "ns-a", &rejected, corev1.ResourceList{"count/secrets": resource.MustParse("100")}),
+			newQuotaIncrease("qi-1", "ns-a", &applied, corev1.ResourceList{"count/secrets": resource.MustParse("2")}),
+			newQuotaIncrease("qi-3", "ns-a", nil, corev1.ResourceList{"count/secrets": resource.MustParse("1")}),
+			rq,
+		}
+		cli := fake.NewClientBuilder().WithScheme(quotainstall.InstallOperatorAPIsOnboarding(runtime.NewScheme())).WithObjects(objs...).WithStatusSubresource(rq).
+			WithIndex(&corev1.Namespace{}, quota.ManagedByIndex, quota.IndexManagedBy).
+			WithIndex(&corev1.ResourceQuota{}, quota.ManagedByIndex, quota.IndexManagedBy).
+			Build()
+
+		cfgProvider = &staticConfigProvider{}
+		handler = server.NewHandler(cli, providerName, cfgProvider)
+	})
+
+	It("should return the active config or 503 if none has been loaded", func() {
+		errResp := map[string]string{}
+		Expect(get(server.PathPrefix+"/config", &errResp)).To(Equal(http.StatusServiceUnavailable))
+		Expect(errResp).To(HaveKey("error"))
+
+		cfgProvider.cfg = &quotav1alpha1.QuotaServiceConfig{}
+		cfgProvider.cfg.SetName(providerName)
+		cfgProvider.cfg.SetGeneration(3)
+		cfgProvider.cfg.Spec.Quotas = []*quotav1alpha1.QuotaDefinition{{Name: "large"}}
+		cfg := &server.ConfigState{}
+		Expect(get(server.PathPrefix+"/config", cfg)).To(Equal(http.StatusOK))
+		Expect(cfg.Name).To(Equal(providerName))
+		Expect(cfg.Generation).To(BeEquivalentTo(3))
+		Expect(cfg.Spec.Quotas).To(ConsistOf(PointTo(MatchFields(IgnoreExtras, Fields{"Name": Equal("large")}))))
+	})
+
+	It("should list all managed namespaces with their quota state", func() {
+		nss := []server.NamespaceState{}
+		Expect(get(server.PathPrefix+"/namespaces", &nss)).To(Equal(http.StatusOK))
+		Expect(nss).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Namespace":       Equal("ns-a"),
+				"QuotaDefinition": Equal("large"),
+				"Mode":            Equal(string(quotav1alpha1.CUMULATIVE)),
+				"Hard":            HaveKeyWithValue(corev1.ResourceName("count/secrets"), resource.MustParse("12")),
+				"Used":            HaveKeyWithValue(corev1.ResourceName("count/secrets"), resource.MustParse("4")),
+				"QuotaIncreases":  BeEmpty(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Namespace":       Equal("ns-b"),
+				"QuotaDefinition": Equal("small"),
+				"Hard":            BeEmpty(),
+			}),
+		))
+	})
+
+	It("should return the state of a single namespace including its QuotaIncreases", func() {
+		ns := &server.NamespaceState{}
+		Expect(get(server.PathPrefix+"/namespaces/ns-a", ns)).To(Equal(http.StatusOK))
+		Expect(ns.QuotaDefinition).To(Equal("large"))
+		Expect(ns.Hard).To(HaveKey(corev1.ResourceName("count/secrets")))
+		Expect(ns.QuotaIncreases).To(HaveExactElements(
+			MatchFields(IgnoreExtras, Fields{
+				"Name":      Equal("qi-1"),
+				"Evaluated": BeTrue(),
+				"Effect":    Equal("count/secrets: 2"),
+				"Rejected":  BeFalse(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":      Equal("qi-2"),
+				"Evaluated": BeTrue(),
+				"Rejected":  BeTrue(),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Name":      Equal("qi-3"),
+				"Evaluated": BeFalse(),
+				"Effect":    BeEmpty(),
+			}),
+		))
+
+		qis := []server.QuotaIncreaseState{}
+		Expect(get(server.PathPrefix+"/namespaces/ns-a/quotaincreases", &qis)).To(Equal(http.StatusOK))
+		Expect(qis).To(HaveLen(3))
+	})
+
+	It("should return the pending changes only in audit mode", func() {
+		Expect(get(server.PathPrefix+"/audit", nil)).To(Equal(http.StatusNotFound))
+
+		handler.WithAuditLog(quota.NewAuditLog())
+		changes := []quota.AuditChange{}
+		Expect(get(server.PathPrefix+"/audit", &changes)).To(Equal(http.StatusOK))
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("should return 404 for namespaces which are not managed by the controller", func() {
+		for _, name := range []string{"ns-foreign", "ns-unmanaged", "ns-missing"} {
+			Expect(get(server.PathPrefix+"/namespaces/"+name, nil)).To(Equal(http.StatusNotFound), "namespace %s", name)
+			Expect(get(server.PathPrefix+"/namespaces/"+name+"/quotaincreases", nil)).To(Equal(http.StatusNotFound), "namespace %s", name)
+		}
+	})
+
+})